@@ -8,6 +8,8 @@ import (
 
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common/logging"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common/tracing"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/knativeeventing"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/knativekafka"
@@ -38,7 +40,9 @@ var (
 func init() {
 	prodConf := zap.NewProductionEncoderConfig()
 	prodConf.EncodeTime = zapcore.ISO8601TimeEncoder
-	logf.SetLogger(zapr.New(zapr.Encoder(zapcore.NewJSONEncoder(prodConf))))
+	// The levels of the named loggers can be changed at runtime through the logging ConfigMap.
+	logf.SetLogger(zapr.New(zapr.Encoder(zapcore.NewJSONEncoder(prodConf)),
+		zapr.Level(logging.DefaultLevels), zapr.RawZapOpts(zap.WrapCore(logging.DefaultLevels.Core))))
 }
 
 func main() {
@@ -113,10 +117,14 @@ func main() {
 		log.Error(err, "Failed to start monitoring")
 	}
 
+	stop := signals.SetupSignalHandler()
+	// Tracing is only enabled if an OTLP endpoint is configured
+	tracing.Setup(stop)
+
 	log.Info("Starting the Cmd.")
 
 	// Start the Cmd
-	if err := mgr.Start(signals.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(stop); err != nil {
 		log.Error(err, "Manager exited non-zero")
 		os.Exit(1)
 	}
@@ -141,6 +149,10 @@ func setupMonitoring(cfg *rest.Config) error {
 		return fmt.Errorf("failed to setup monitoring resources: %w", err)
 	}
 
+	if err := common.MigrateToUserWorkloadMonitoring(cl); err != nil {
+		return fmt.Errorf("failed to migrate user namespaces to user-workload monitoring: %w", err)
+	}
+
 	if err := common.SetupServerlessOperatorServiceMonitor(cfg, cl, metricsPort, metricsHost, operatorMetricsPort); err != nil {
 		return fmt.Errorf("failed to setup the Service monitor: %w", err)
 	}
@@ -0,0 +1,53 @@
+// render prints the resources the operator applies for a KnativeServing, KnativeEventing or
+// KnativeKafka without contacting an API server, e.g.
+//
+//	go run ./cmd/render --instance knativeserving.yaml --env-file operator.env
+//
+// The env file sets the environment of the operator, like the manifest paths and image overrides.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/render"
+)
+
+func main() {
+	instance := flag.String("instance", "", "Path to the KnativeServing, KnativeEventing or KnativeKafka YAML")
+	envFile := flag.String("env-file", "", "Path to a file with KEY=VALUE lines setting the environment of the operator, relative paths being resolved against its directory")
+	domain := flag.String("domain", "", "Ingress domain of the cluster")
+	flag.Parse()
+
+	if err := run(*instance, *envFile, render.Options{Domain: *domain}); err != nil {
+		fmt.Fprintln(os.Stderr, "render:", err)
+		os.Exit(1)
+	}
+}
+
+func run(instance, envFile string, opts render.Options) error {
+	if instance == "" {
+		return fmt.Errorf("--instance is required")
+	}
+	if envFile != "" {
+		if err := render.SetEnvFromFile(envFile); err != nil {
+			return fmt.Errorf("failed to read env file: %w", err)
+		}
+	}
+	data, err := ioutil.ReadFile(instance)
+	if err != nil {
+		return fmt.Errorf("failed to read instance: %w", err)
+	}
+	resources, err := render.Render(data, opts)
+	if err != nil {
+		return err
+	}
+	out, err := render.YAML(resources)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
@@ -0,0 +1,12 @@
+package apis
+
+import (
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	messagingv1 "knative.dev/eventing/pkg/apis/messaging/v1"
+)
+
+func init() {
+	// Register the types with the Scheme so the components can map objects to GroupVersionKinds and back
+	// Add Knative Eventing broker and messaging schemes used in Telemetry
+	AddToSchemes = append(AddToSchemes, eventingv1.AddToScheme, messagingv1.AddToScheme)
+}
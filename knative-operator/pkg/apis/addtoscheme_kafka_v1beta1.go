@@ -1,11 +1,12 @@
 package apis
 
 import (
+	kafkachannelv1beta1 "knative.dev/eventing-contrib/kafka/channel/pkg/apis/messaging/v1beta1"
 	kafkasourcev1beta1 "knative.dev/eventing-contrib/kafka/source/pkg/apis/sources/v1beta1"
 )
 
 func init() {
 	// Register the types with the Scheme so the components can map objects to GroupVersionKinds and back
-	// Add Knative Eventing Kafka source scheme used in Telemetry
-	AddToSchemes = append(AddToSchemes, kafkasourcev1beta1.AddToScheme)
+	// Add Knative Eventing Kafka source and channel schemes used in Telemetry
+	AddToSchemes = append(AddToSchemes, kafkasourcev1beta1.AddToScheme, kafkachannelv1beta1.AddToScheme)
 }
@@ -12,6 +12,11 @@ var (
 	)
 )
 
+// GetConditionSet returns the condition set of KnativeKafka.
+func (*KnativeKafkaStatus) GetConditionSet() apis.ConditionSet {
+	return kafkaCondSet
+}
+
 // InitializeConditions initializes conditions of an KnativeKafkaStatus
 func (is *KnativeKafkaStatus) InitializeConditions() {
 	kafkaCondSet.Manage(is).InitializeConditions()
@@ -0,0 +1,206 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	mfc "github.com/manifestival/controller-runtime-client"
+	mf "github.com/manifestival/manifestival"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// DashboardTargetsEnvKey lists the targets the dashboards are installed to, separated by commas.
+	// Defaults to DashboardTargetConsole.
+	DashboardTargetsEnvKey = "DASHBOARD_TARGETS"
+	// DashboardTargetConsole installs the dashboards as ConfigMaps in openshift-config-managed,
+	// where the OpenShift console picks them up.
+	DashboardTargetConsole = "console"
+	// DashboardTargetGrafanaOperator installs the dashboards as GrafanaDashboards of grafana-operator.
+	DashboardTargetGrafanaOperator = "grafana-operator"
+
+	// GrafanaDashboardNamespaceEnvKey is the namespace of the GrafanaDashboards, the namespace of the
+	// operator by default.
+	GrafanaDashboardNamespaceEnvKey = "GRAFANA_DASHBOARD_NAMESPACE"
+	// GrafanaDashboardLabelsEnvKey are the labels of the GrafanaDashboards, e.g. "app=grafana", matching
+	// the dashboardLabelSelector of the Grafana instance.
+	GrafanaDashboardLabelsEnvKey = "GRAFANA_DASHBOARD_LABELS"
+)
+
+// GrafanaDashboardGVK is the kind of the dashboards of grafana-operator.
+var GrafanaDashboardGVK = schema.GroupVersionKind{Group: "integreatly.org", Version: "v1alpha1", Kind: "GrafanaDashboard"}
+
+// DashboardTargets returns the configured dashboard targets.
+func DashboardTargets() map[string]bool {
+	value := os.Getenv(DashboardTargetsEnvKey)
+	if value == "" {
+		value = DashboardTargetConsole
+	}
+	targets := map[string]bool{}
+	for _, target := range strings.Split(value, ",") {
+		switch target = strings.TrimSpace(target); target {
+		case DashboardTargetConsole, DashboardTargetGrafanaOperator:
+			targets[target] = true
+		case "":
+		default:
+			Log.Info("Ignoring unknown dashboard target", "target", target)
+		}
+	}
+	return targets
+}
+
+// DashboardManifests returns the dashboards of the given manifest of dashboard ConfigMaps, as they're
+// installed to the configured targets, and as they're installed to the other ones, so these can be removed.
+// The console dashboards are skipped if openshift-config-managed doesn't exist.
+func DashboardManifests(raw mf.Manifest, owner mf.Transformer, api client.Client) (configured mf.Manifest, others mf.Manifest, err error) {
+	targets := DashboardTargets()
+	var resources, otherResources []unstructured.Unstructured
+
+	console, err := raw.Transform(mf.InjectNamespace(ConfigManagedNamespace), owner)
+	if err != nil {
+		return mf.Manifest{}, mf.Manifest{}, fmt.Errorf("failed to transform dashboard manifest: %w", err)
+	}
+	if !targets[DashboardTargetConsole] {
+		otherResources = append(otherResources, console.Resources()...)
+	} else if err := api.Get(context.TODO(), client.ObjectKey{Name: ConfigManagedNamespace}, &corev1.Namespace{}); apierrors.IsNotFound(err) {
+		Log.Info(fmt.Sprintf("namespace %q not found. Skipping to create console dashboards.", ConfigManagedNamespace))
+	} else if err != nil {
+		return mf.Manifest{}, mf.Manifest{}, fmt.Errorf("failed to get namespace %q: %w", ConfigManagedNamespace, err)
+	} else {
+		resources = append(resources, console.Resources()...)
+	}
+
+	grafana, err := grafanaDashboards(raw, owner)
+	if err != nil {
+		return mf.Manifest{}, mf.Manifest{}, err
+	}
+	if targets[DashboardTargetGrafanaOperator] {
+		resources = append(resources, grafana...)
+	} else {
+		otherResources = append(otherResources, grafana...)
+	}
+
+	if configured, err = dashboardManifest(resources, api); err != nil {
+		return mf.Manifest{}, mf.Manifest{}, err
+	}
+	if others, err = dashboardManifest(otherResources, api); err != nil {
+		return mf.Manifest{}, mf.Manifest{}, err
+	}
+	return configured, others, nil
+}
+
+// ApplyDashboards applies the given dashboards. GrafanaDashboards are skipped while they're not served
+// by the cluster, e.g. until grafana-operator is installed, as are their watches, see WatchGrafanaDashboards.
+func ApplyDashboards(manifest mf.Manifest) error {
+	grafana := mf.ByGVK(GrafanaDashboardGVK)
+	if err := manifest.Filter(mf.Not(grafana)).Apply(); err != nil {
+		return err
+	}
+	if err := manifest.Filter(grafana).Apply(); err != nil {
+		if !meta.IsNoMatchError(err) {
+			return err
+		}
+		Log.Info("GrafanaDashboards not served by the cluster, not installing them")
+	}
+	return nil
+}
+
+// DeleteDashboards deletes the given dashboards, tolerating GrafanaDashboards not being served by the cluster.
+func DeleteDashboards(manifest mf.Manifest) error {
+	for _, resource := range manifest.Resources() {
+		if err := manifest.Client.Delete(&resource, mf.IgnoreNotFound(true)); err != nil && !meta.IsNoMatchError(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// WatchGrafanaDashboards makes the controller watch the GrafanaDashboards, if they're a configured
+// dashboard target and served by the cluster.
+func WatchGrafanaDashboards(c controller.Controller, mgr manager.Manager, h handler.EventHandler, predicates ...predicate.Predicate) error {
+	if !DashboardTargets()[DashboardTargetGrafanaOperator] {
+		return nil
+	}
+	if _, err := mgr.GetRESTMapper().RESTMapping(GrafanaDashboardGVK.GroupKind(), GrafanaDashboardGVK.Version); err != nil {
+		if meta.IsNoMatchError(err) {
+			Log.Info("GrafanaDashboards not served by the cluster, not watching them")
+			return nil
+		}
+		return err
+	}
+	dashboard := &unstructured.Unstructured{}
+	dashboard.SetGroupVersionKind(GrafanaDashboardGVK)
+	return c.Watch(&source.Kind{Type: dashboard}, h, predicates...)
+}
+
+// grafanaDashboards converts the dashboard ConfigMaps to GrafanaDashboards, one per dashboard.
+func grafanaDashboards(raw mf.Manifest, owner mf.Transformer) ([]unstructured.Unstructured, error) {
+	namespace := os.Getenv(GrafanaDashboardNamespaceEnvKey)
+	if namespace == "" {
+		namespace = os.Getenv(NamespaceEnvKey)
+	}
+	dashboardLabels, err := labels.ConvertSelectorToLabelsMap(os.Getenv(GrafanaDashboardLabelsEnvKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", GrafanaDashboardLabelsEnvKey, err)
+	}
+
+	var dashboards []unstructured.Unstructured
+	for _, cm := range raw.Filter(mf.ByKind("ConfigMap")).Resources() {
+		data, _, err := unstructured.NestedStringMap(cm.Object, "data")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dashboard %s: %w", cm.GetName(), err)
+		}
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			name := cm.GetName()
+			if len(data) > 1 {
+				name += "-" + strings.TrimSuffix(key, ".json")
+			}
+			dashboard := unstructured.Unstructured{Object: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"name": key,
+					"json": data[key],
+				},
+			}}
+			dashboard.SetGroupVersionKind(GrafanaDashboardGVK)
+			dashboard.SetName(name)
+			dashboard.SetNamespace(namespace)
+			if len(dashboardLabels) > 0 {
+				dashboard.SetLabels(dashboardLabels)
+			}
+			if owner != nil {
+				if err := owner(&dashboard); err != nil {
+					return nil, err
+				}
+			}
+			dashboards = append(dashboards, dashboard)
+		}
+	}
+	return dashboards, nil
+}
+
+func dashboardManifest(resources []unstructured.Unstructured, api client.Client) (mf.Manifest, error) {
+	manifest, err := mf.ManifestFrom(mf.Slice(resources), mf.UseClient(mfc.NewClient(api)), mf.UseLogger(Log.WithName("mf")))
+	if err != nil {
+		return mf.Manifest{}, fmt.Errorf("failed to build dashboard manifest: %w", err)
+	}
+	return manifest, nil
+}
@@ -0,0 +1,169 @@
+package common
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	mf "github.com/manifestival/manifestival"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func dashboardConfigMap() unstructured.Unstructured {
+	cm := unstructured.Unstructured{Object: map[string]interface{}{
+		"data": map[string]interface{}{"knative.json": `{"title": "Knative"}`},
+	}}
+	cm.SetAPIVersion("v1")
+	cm.SetKind("ConfigMap")
+	cm.SetName("grafana-dashboard-definition-knative")
+	cm.SetLabels(map[string]string{"console.openshift.io/dashboard": "true"})
+	return cm
+}
+
+func TestDashboardManifests(t *testing.T) {
+	defer os.Unsetenv(DashboardTargetsEnvKey)
+	defer os.Unsetenv(GrafanaDashboardNamespaceEnvKey)
+	defer os.Unsetenv(GrafanaDashboardLabelsEnvKey)
+	os.Setenv(GrafanaDashboardNamespaceEnvKey, "grafana")
+	os.Setenv(GrafanaDashboardLabelsEnvKey, "app=grafana")
+
+	raw, err := mf.ManifestFrom(mf.Slice([]unstructured.Unstructured{dashboardConfigMap()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := SetAnnotations(map[string]string{ServingOwnerName: "knative-serving"})
+	configManagedNs := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ConfigManagedNamespace}}
+
+	tests := []struct {
+		name          string
+		targets       string
+		configManaged bool
+		configured    []string
+		others        []string
+	}{{
+		name:          "default",
+		configManaged: true,
+		configured:    []string{"ConfigMap"},
+		others:        []string{"GrafanaDashboard"},
+	}, {
+		name:   "console without openshift-config-managed",
+		others: []string{"GrafanaDashboard"},
+	}, {
+		name:       "grafana-operator",
+		targets:    DashboardTargetGrafanaOperator,
+		configured: []string{"GrafanaDashboard"},
+		others:     []string{"ConfigMap"},
+	}, {
+		name:          "both",
+		targets:       "console, grafana-operator",
+		configManaged: true,
+		configured:    []string{"ConfigMap", "GrafanaDashboard"},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			os.Setenv(DashboardTargetsEnvKey, test.targets)
+			api := fake.NewFakeClient()
+			if test.configManaged {
+				api = fake.NewFakeClient(configManagedNs)
+			}
+			configured, others, err := DashboardManifests(raw, owner, api)
+			if err != nil {
+				t.Fatalf("DashboardManifests() = %v", err)
+			}
+			if got := kinds(configured); !equal(got, test.configured) {
+				t.Errorf("Configured kinds = %v, want %v", got, test.configured)
+			}
+			if got := kinds(others); !equal(got, test.others) {
+				t.Errorf("Other kinds = %v, want %v", got, test.others)
+			}
+
+			for _, u := range append(configured.Resources(), others.Resources()...) {
+				if u.GetAnnotations()[ServingOwnerName] != "knative-serving" {
+					t.Errorf("%s annotations = %v, want the owner", u.GetKind(), u.GetAnnotations())
+				}
+				if u.GetKind() != "GrafanaDashboard" {
+					continue
+				}
+				if u.GetNamespace() != "grafana" || u.GetLabels()["app"] != "grafana" {
+					t.Errorf("GrafanaDashboard %s/%s has labels %v, want grafana/app=grafana", u.GetNamespace(), u.GetName(), u.GetLabels())
+				}
+				if json, _, _ := unstructured.NestedString(u.Object, "spec", "json"); json != `{"title": "Knative"}` {
+					t.Errorf("GrafanaDashboard json = %q", json)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyDashboards(t *testing.T) {
+	cm := dashboardConfigMap()
+	cm.SetNamespace(ConfigManagedNamespace)
+	api := fake.NewFakeClient()
+
+	dashboard := unstructured.Unstructured{}
+	dashboard.SetGroupVersionKind(GrafanaDashboardGVK)
+	dashboard.SetNamespace("grafana")
+	dashboard.SetName(cm.GetName())
+	manifest, err := dashboardManifest([]unstructured.Unstructured{dashboard, cm}, api)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// GrafanaDashboards not being served by the cluster are skipped.
+	if err := ApplyDashboards(manifest); err != nil {
+		t.Fatalf("ApplyDashboards() = %v", err)
+	}
+	err = api.Get(context.TODO(), types.NamespacedName{Namespace: ConfigManagedNamespace, Name: cm.GetName()}, &corev1.ConfigMap{})
+	if err != nil {
+		t.Errorf("ConfigMap not applied: %v", err)
+	}
+}
+
+func TestDeleteDashboards(t *testing.T) {
+	cm := dashboardConfigMap()
+	cm.SetNamespace(ConfigManagedNamespace)
+	api := fake.NewFakeClient(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: ConfigManagedNamespace, Name: cm.GetName()}})
+
+	dashboard := unstructured.Unstructured{}
+	dashboard.SetGroupVersionKind(GrafanaDashboardGVK)
+	dashboard.SetNamespace("grafana")
+	dashboard.SetName(cm.GetName())
+	manifest, err := dashboardManifest([]unstructured.Unstructured{cm, dashboard}, api)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// GrafanaDashboards not being served by the cluster are fine.
+	if err := DeleteDashboards(manifest); err != nil {
+		t.Fatalf("DeleteDashboards() = %v", err)
+	}
+	err = api.Get(context.TODO(), types.NamespacedName{Namespace: ConfigManagedNamespace, Name: cm.GetName()}, &corev1.ConfigMap{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("ConfigMap not deleted: %v", err)
+	}
+}
+
+func kinds(manifest mf.Manifest) []string {
+	var kinds []string
+	for _, u := range manifest.Resources() {
+		kinds = append(kinds, u.GetKind())
+	}
+	return kinds
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
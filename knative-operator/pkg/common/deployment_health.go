@@ -0,0 +1,55 @@
+package common
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeploymentComponent returns the Knative component a deployment belongs to, or an empty string
+// if the deployment isn't managed by the operator. Serving and Eventing deployments are owned by
+// their KnativeServing and KnativeEventing instances, while the Kourier and Kafka deployments are
+// marked with the owner annotations.
+func DeploymentComponent(obj metav1.Object) string {
+	annotations := obj.GetAnnotations()
+	switch {
+	case annotations[KafkaOwnerName] != "":
+		return "kafka"
+	case annotations[ServingOwnerName] != "":
+		return "serving"
+	case annotations[EventingOwnerName] != "":
+		return "eventing"
+	}
+	if owner := metav1.GetControllerOf(obj); owner != nil {
+		switch owner.Kind {
+		case "KnativeServing":
+			return "serving"
+		case "KnativeEventing":
+			return "eventing"
+		}
+	}
+	return ""
+}
+
+// IsDeploymentReady returns true if the current generation of the deployment has been observed
+// and all of its desired replicas are ready.
+func IsDeploymentReady(d *appsv1.Deployment) bool {
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+	return d.Status.ObservedGeneration >= d.Generation && d.Status.ReadyReplicas >= desired
+}
+
+// ReportDeploymentReadiness sets the readiness gauge of a deployment of the given component.
+func ReportDeploymentReadiness(component string, d *appsv1.Deployment) {
+	ready := 0.0
+	if IsDeploymentReady(d) {
+		ready = 1
+	}
+	componentDeploymentReady.WithLabelValues(component, d.Namespace, d.Name).Set(ready)
+}
+
+// ForgetDeploymentReadiness removes the readiness gauge of a deleted deployment.
+func ForgetDeploymentReadiness(component, namespace, name string) {
+	componentDeploymentReady.DeleteLabelValues(component, namespace, name)
+}
@@ -0,0 +1,324 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"knative.dev/pkg/apis"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// DriftPolicyAnnotation sets how resources managed for an instance are handled if they have
+	// been changed by someone else: DriftPolicyRevert (the default) reverts them to the configuration
+	// of the operator, DriftPolicyReport only reports them.
+	DriftPolicyAnnotation = "serverless.openshift.io/drift-policy"
+	// DriftPolicyRevert reverts drifted resources.
+	DriftPolicyRevert = "revert"
+	// DriftPolicyReport reports drifted resources and keeps their drifted fields. Other changes
+	// to the resources, e.g. on upgrades, are still applied.
+	DriftPolicyReport = "report"
+
+	// ResourcesInSync reflects whether the resources managed for an instance match the configuration
+	// of the operator, i.e. no drift has been kept under DriftPolicyReport. It's informational and
+	// doesn't affect the Ready condition.
+	ResourcesInSync apis.ConditionType = "ResourcesInSync"
+
+	// lastAppliedAnnotation holds the configuration Manifestival applied last.
+	lastAppliedAnnotation = corev1.LastAppliedConfigAnnotation
+	// maxReportedFields limits the fields listed in a drift event.
+	maxReportedFields = 10
+)
+
+// DriftReporter detects and reports drift of the resources the operator applies for an instance,
+// i.e. live resources differing from the configuration the operator applied last.
+type DriftReporter struct {
+	// Component is the Knative component, used as metric label.
+	Component string
+	// Instance is the instance the resources are managed for.
+	Instance controllerutil.Object
+	// Recorder records an event for each drifted resource, if set.
+	Recorder record.EventRecorder
+
+	// kept are the resources whose drift was kept under DriftPolicyReport.
+	kept []string
+}
+
+// Client wraps the client used to apply the manifests of the instance. Drift is detected when
+// Manifestival updates a resource. The drifted fields are reverted or kept depending on the policy,
+// other changes are always applied. A nil DriftReporter returns the client as is.
+func (d *DriftReporter) Client(c client.Client) client.Client {
+	if d == nil {
+		return c
+	}
+	return &driftClient{Client: c, reporter: d, live: map[string]*unstructured.Unstructured{}}
+}
+
+// Policy returns the drift policy of the instance.
+func (d *DriftReporter) Policy() string {
+	if d.Instance.GetAnnotations()[DriftPolicyAnnotation] == DriftPolicyReport {
+		return DriftPolicyReport
+	}
+	return DriftPolicyRevert
+}
+
+// MarkResourcesInSync marks the ResourcesInSync condition, reflecting the drift kept by the clients
+// of the reporter. It's meant to be called once all manifests of the instance have been applied.
+func (d *DriftReporter) MarkResourcesInSync(conditions apis.ConditionManager) {
+	if len(d.kept) == 0 {
+		conditions.MarkTrue(ResourcesInSync)
+		return
+	}
+	kept := d.kept
+	if len(kept) > maxReportedFields {
+		kept = append(kept[:maxReportedFields:maxReportedFields], fmt.Sprintf("and %d more", len(kept)-maxReportedFields))
+	}
+	conditions.MarkFalse(ResourcesInSync, "DriftNotReverted",
+		"Drifted fields are kept as the drift policy is %s: %s", DriftPolicyReport, strings.Join(kept, ", "))
+}
+
+// ForgetDrift deletes the drift metrics of all resources of the component, e.g. once its instance is deleted.
+func ForgetDrift(component string) {
+	driftSeries.forgetComponent(component)
+}
+
+func (d *DriftReporter) report(live *unstructured.Unstructured, fields []string, reverted bool) {
+	labels := driftLabels(d.Component, live)
+	action := "reverted"
+	if reverted {
+		driftReverted.With(labels).Inc()
+		resourceDrift.With(labels).Set(0)
+	} else {
+		action = "not reverted as the drift policy is " + DriftPolicyReport
+		resourceDrift.With(labels).Set(1)
+		d.kept = append(d.kept, live.GetKind()+" "+qualifiedName(live))
+	}
+	driftSeries.add(labels)
+	if len(fields) > maxReportedFields {
+		fields = append(fields[:maxReportedFields], fmt.Sprintf("and %d more", len(fields)-maxReportedFields))
+	}
+	message := fmt.Sprintf("%s %s drifted from the applied configuration in %s, %s",
+		live.GetKind(), qualifiedName(live), strings.Join(fields, ", "), action)
+	log.Info(message, "component", d.Component)
+	if d.Recorder != nil {
+		d.Recorder.Event(d.Instance, corev1.EventTypeWarning, "ResourceDrifted", message)
+	}
+}
+
+// driftClient remembers the live resources read by Manifestival, so their drift can be determined
+// when they are updated. It's meant to be used for a single reconciliation.
+type driftClient struct {
+	client.Client
+	reporter *DriftReporter
+	live     map[string]*unstructured.Unstructured
+}
+
+func (c *driftClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	err := c.Client.Get(ctx, key, obj)
+	if u, ok := obj.(*unstructured.Unstructured); ok && err == nil {
+		c.live[driftKey(u)] = u.DeepCopy()
+		labels := driftLabels(c.reporter.Component, u)
+		resourceDrift.With(labels).Set(0)
+		driftSeries.add(labels)
+	}
+	return err
+}
+
+func (c *driftClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return c.Client.Update(ctx, obj, opts...)
+	}
+	live, ok := c.live[driftKey(u)]
+	if !ok {
+		return c.Client.Update(ctx, obj, opts...)
+	}
+	paths, err := driftedPaths(live)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return c.Client.Update(ctx, obj, opts...)
+	}
+	fields := formatPaths(paths)
+	if c.reporter.Policy() == DriftPolicyReport {
+		// Keep the drifted fields, but apply any other change.
+		for _, path := range paths {
+			u.Object = keepField(u.Object, live.Object, path).(map[string]interface{})
+		}
+		c.reporter.report(live, fields, false)
+		if reflect.DeepEqual(u.Object, live.Object) {
+			return nil
+		}
+		return c.Client.Update(ctx, u, opts...)
+	}
+	if err := c.Client.Update(ctx, obj, opts...); err != nil {
+		return err
+	}
+	c.reporter.report(live, fields, true)
+	return nil
+}
+
+func (c *driftClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	if err := c.Client.Delete(ctx, obj, opts...); err != nil {
+		return err
+	}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		driftSeries.forget(driftLabels(c.reporter.Component, u))
+	}
+	return nil
+}
+
+// DriftedFields returns the paths of the fields of the live resource that differ from the
+// configuration last applied by the operator. Fields the operator doesn't set are ignored.
+func DriftedFields(live *unstructured.Unstructured) ([]string, error) {
+	paths, err := driftedPaths(live)
+	if err != nil {
+		return nil, err
+	}
+	return formatPaths(paths), nil
+}
+
+// fieldPath is the path to a field, made of map keys and list indices.
+type fieldPath []interface{}
+
+func (p fieldPath) String() string {
+	var b strings.Builder
+	for _, segment := range p {
+		switch segment := segment.(type) {
+		case int:
+			fmt.Fprintf(&b, "[%d]", segment)
+		default:
+			if b.Len() > 0 {
+				b.WriteString(".")
+			}
+			fmt.Fprint(&b, segment)
+		}
+	}
+	return b.String()
+}
+
+func driftedPaths(live *unstructured.Unstructured) ([]fieldPath, error) {
+	lastApplied, ok := live.GetAnnotations()[lastAppliedAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	applied := &unstructured.Unstructured{}
+	if err := applied.UnmarshalJSON([]byte(lastApplied)); err != nil {
+		return nil, fmt.Errorf("failed to parse the last applied configuration of %s %s: %w", live.GetKind(), qualifiedName(live), err)
+	}
+
+	var paths []fieldPath
+	for key, value := range applied.Object {
+		switch key {
+		case "apiVersion", "kind", "status":
+			continue
+		case "metadata":
+			metadata, _ := value.(map[string]interface{})
+			for _, key := range []string{"labels", "annotations"} {
+				if value, ok := metadata[key]; ok {
+					liveValue, _, _ := unstructured.NestedFieldNoCopy(live.Object, "metadata", key)
+					paths = diffPaths(paths, fieldPath{"metadata", key}, value, liveValue)
+				}
+			}
+		default:
+			paths = diffPaths(paths, fieldPath{key}, value, live.Object[key])
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool { return paths[i].String() < paths[j].String() })
+	return paths, nil
+}
+
+func formatPaths(paths []fieldPath) []string {
+	fields := make([]string, 0, len(paths))
+	for _, path := range paths {
+		fields = append(fields, path.String())
+	}
+	return fields
+}
+
+// diffPaths appends the paths of the fields set in applied that differ in live.
+func diffPaths(paths []fieldPath, path fieldPath, applied, live interface{}) []fieldPath {
+	switch applied := applied.(type) {
+	case map[string]interface{}:
+		live, ok := live.(map[string]interface{})
+		if !ok {
+			return append(paths, path)
+		}
+		for key, value := range applied {
+			paths = diffPaths(paths, append(path[:len(path):len(path)], key), value, live[key])
+		}
+		return paths
+	case []interface{}:
+		live, ok := live.([]interface{})
+		if !ok || len(live) != len(applied) {
+			return append(paths, path)
+		}
+		for i := range applied {
+			paths = diffPaths(paths, append(path[:len(path):len(path)], i), applied[i], live[i])
+		}
+		return paths
+	case nil:
+		// Unset fields are defaulted by the API server.
+		return paths
+	}
+	if !reflect.DeepEqual(applied, live) {
+		return append(paths, path)
+	}
+	return paths
+}
+
+// keepField returns obj with the field at the given path set to its value in live, or removed if
+// live doesn't have it.
+func keepField(obj, live interface{}, path fieldPath) interface{} {
+	if live == nil {
+		return nil
+	}
+	if len(path) == 0 {
+		return runtime.DeepCopyJSONValue(live)
+	}
+	switch key := path[0].(type) {
+	case string:
+		liveMap, _ := live.(map[string]interface{})
+		objMap, ok := obj.(map[string]interface{})
+		if !ok {
+			objMap = map[string]interface{}{}
+		}
+		liveValue, found := liveMap[key]
+		if !found {
+			delete(objMap, key)
+			return objMap
+		}
+		objMap[key] = keepField(objMap[key], liveValue, path[1:])
+		return objMap
+	case int:
+		liveList, _ := live.([]interface{})
+		objList, ok := obj.([]interface{})
+		if !ok || key >= len(objList) || key >= len(liveList) {
+			// The list changed shape, keep it as a whole.
+			return runtime.DeepCopyJSONValue(live)
+		}
+		objList[key] = keepField(objList[key], liveList[key], path[1:])
+		return objList
+	}
+	return obj
+}
+
+func driftKey(u *unstructured.Unstructured) string {
+	return u.GroupVersionKind().String() + "/" + qualifiedName(u)
+}
+
+func qualifiedName(u *unstructured.Unstructured) string {
+	if u.GetNamespace() == "" {
+		return u.GetName()
+	}
+	return u.GetNamespace() + "/" + u.GetName()
+}
@@ -0,0 +1,82 @@
+package common
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	resourceDrift = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "serverless_managed_resource_drift",
+			Help: "Whether a resource managed by the operator has drifted from its applied configuration and was not reverted",
+		},
+		[]string{"component", "kind", "namespace", "name"},
+	)
+
+	driftReverted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "serverless_managed_resource_drift_reverted_total",
+			Help: "Number of times a resource managed by the operator was reverted to its applied configuration",
+		},
+		[]string{"component", "kind", "namespace", "name"},
+	)
+)
+
+func init() {
+	// Register custom metrics with the global prometheus registry
+	metrics.Registry.MustRegister(resourceDrift, driftReverted)
+}
+
+func driftLabels(component string, u *unstructured.Unstructured) prometheus.Labels {
+	return prometheus.Labels{
+		"component": component,
+		"kind":      u.GetKind(),
+		"namespace": u.GetNamespace(),
+		"name":      u.GetName(),
+	}
+}
+
+// driftSeries tracks the label sets of the drift metrics, so the series of deleted resources can be
+// deleted as well.
+var driftSeries = &seriesSet{series: map[string]prometheus.Labels{}}
+
+type seriesSet struct {
+	mu     sync.Mutex
+	series map[string]prometheus.Labels
+}
+
+func seriesKey(labels prometheus.Labels) string {
+	return labels["component"] + "/" + labels["kind"] + "/" + labels["namespace"] + "/" + labels["name"]
+}
+
+func (s *seriesSet) add(labels prometheus.Labels) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.series[seriesKey(labels)] = labels
+}
+
+// forget deletes the series with the given labels.
+func (s *seriesSet) forget(labels prometheus.Labels) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resourceDrift.Delete(labels)
+	driftReverted.Delete(labels)
+	delete(s.series, seriesKey(labels))
+}
+
+// forgetComponent deletes the series of all resources of the given component.
+func (s *seriesSet) forgetComponent(component string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, labels := range s.series {
+		if labels["component"] == component {
+			resourceDrift.Delete(labels)
+			driftReverted.Delete(labels)
+			delete(s.series, key)
+		}
+	}
+}
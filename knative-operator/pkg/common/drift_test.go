@@ -0,0 +1,203 @@
+package common_test
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	mfc "github.com/manifestival/controller-runtime-client"
+	mf "github.com/manifestival/manifestival"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/pkg/apis"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func TestDriftedFields(t *testing.T) {
+	live := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name": "test",
+			"annotations": map[string]interface{}{
+				corev1.LastAppliedConfigAnnotation: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test","labels":{"app":"test"}},"data":{"a":"1","b":"2"},"list":[1,2]}`,
+			},
+			"labels": map[string]interface{}{"app": "changed", "extra": "label"},
+		},
+		"data": map[string]interface{}{"a": "1", "b": "changed", "c": "added"},
+		"list": []interface{}{int64(1), int64(2)},
+	}}
+
+	fields, err := common.DriftedFields(live)
+	if err != nil {
+		t.Fatalf("DriftedFields() = %v", err)
+	}
+	want := []string{"data.b", "metadata.labels.app"}
+	if strings.Join(fields, ",") != strings.Join(want, ",") {
+		t.Errorf("DriftedFields() = %v, want %v", fields, want)
+	}
+}
+
+func TestDriftReporter(t *testing.T) {
+	cases := []struct {
+		name     string
+		policy   string
+		wantData string
+		wantSync corev1.ConditionStatus
+	}{{
+		name:     "revert",
+		wantData: "applied",
+		wantSync: corev1.ConditionTrue,
+	}, {
+		name:     "report",
+		policy:   common.DriftPolicyReport,
+		wantData: "changed",
+		wantSync: corev1.ConditionFalse,
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ks := &servingv1alpha1.KnativeServing{
+				ObjectMeta: metav1.ObjectMeta{Name: "knative-serving", Namespace: "knative-serving"},
+			}
+			if c.policy != "" {
+				ks.Annotations = map[string]string{common.DriftPolicyAnnotation: c.policy}
+			}
+			recorder := record.NewFakeRecorder(10)
+			drift := &common.DriftReporter{Component: "test", Instance: ks, Recorder: recorder}
+			api := fake.NewFakeClient()
+
+			cm := &unstructured.Unstructured{}
+			cm.SetAPIVersion("v1")
+			cm.SetKind("ConfigMap")
+			cm.SetName("test")
+			cm.SetNamespace("knative-serving")
+			if err := unstructured.SetNestedField(cm.Object, "applied", "data", "key"); err != nil {
+				t.Fatal(err)
+			}
+			apply := func() {
+				manifest, err := mf.ManifestFrom(mf.Slice([]unstructured.Unstructured{*cm.DeepCopy()}),
+					mf.UseClient(mfc.NewClient(drift.Client(api))))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := manifest.Apply(); err != nil {
+					t.Fatalf("Apply() = %v", err)
+				}
+			}
+			apply()
+			if len(recorder.Events) != 0 {
+				t.Fatalf("Unexpected event on creation: %s", <-recorder.Events)
+			}
+
+			live := &corev1.ConfigMap{}
+			if err := api.Get(context.TODO(), client.ObjectKey{Name: "test", Namespace: "knative-serving"}, live); err != nil {
+				t.Fatal(err)
+			}
+			live.Data["key"] = "changed"
+			if err := api.Update(context.TODO(), live); err != nil {
+				t.Fatal(err)
+			}
+			// Changes of the configuration are applied under any policy.
+			if err := unstructured.SetNestedField(cm.Object, "new", "data", "other"); err != nil {
+				t.Fatal(err)
+			}
+			drift = &common.DriftReporter{Component: "test", Instance: ks, Recorder: recorder}
+			apply()
+
+			if len(recorder.Events) != 1 {
+				t.Fatalf("Got %d events, want 1", len(recorder.Events))
+			}
+			if event := <-recorder.Events; !strings.Contains(event, "ResourceDrifted") || !strings.Contains(event, "data.key") {
+				t.Errorf("Event = %q, want a ResourceDrifted event naming data.key", event)
+			}
+			if err := api.Get(context.TODO(), client.ObjectKey{Name: "test", Namespace: "knative-serving"}, live); err != nil {
+				t.Fatal(err)
+			}
+			if live.Data["key"] != c.wantData {
+				t.Errorf("Data = %q, want %q", live.Data["key"], c.wantData)
+			}
+			if live.Data["other"] != "new" {
+				t.Errorf("Data = %q, want the configuration change to be applied", live.Data["other"])
+			}
+
+			conditions := apis.NewLivingConditionSet().Manage(&ks.Status)
+			drift.MarkResourcesInSync(conditions)
+			if cond := conditions.GetCondition(common.ResourcesInSync); cond == nil || cond.Status != c.wantSync {
+				t.Errorf("ResourcesInSync = %v, want %s", cond, c.wantSync)
+			}
+		})
+	}
+}
+
+func TestForgetDrift(t *testing.T) {
+	ks := &servingv1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{Name: "knative-serving", Namespace: "knative-serving"},
+	}
+	drift := &common.DriftReporter{Component: "forget-test", Instance: ks}
+	api := drift.Client(fake.NewFakeClient())
+
+	var resources []unstructured.Unstructured
+	for _, name := range []string{"deleted", "kept"} {
+		cm := unstructured.Unstructured{}
+		cm.SetAPIVersion("v1")
+		cm.SetKind("ConfigMap")
+		cm.SetName(name)
+		cm.SetNamespace("knative-serving")
+		resources = append(resources, cm)
+	}
+	manifest, err := mf.ManifestFrom(mf.Slice(resources), mf.UseClient(mfc.NewClient(api)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Apply twice, as the series are only created for existing resources.
+	for i := 0; i < 2; i++ {
+		if err := manifest.Apply(); err != nil {
+			t.Fatalf("Apply() = %v", err)
+		}
+	}
+	assertDriftSeries(t, "deleted", "kept")
+
+	if err := manifest.Client.Delete(&resources[0]); err != nil {
+		t.Fatalf("Delete() = %v", err)
+	}
+	assertDriftSeries(t, "kept")
+
+	common.ForgetDrift("forget-test")
+	assertDriftSeries(t)
+}
+
+// assertDriftSeries asserts the names of the resources with a drift series of the forget-test component.
+func assertDriftSeries(t *testing.T, want ...string) {
+	t.Helper()
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, family := range families {
+		if family.GetName() != "serverless_managed_resource_drift" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["component"] == "forget-test" {
+				got = append(got, labels["name"])
+			}
+		}
+	}
+	sort.Strings(got)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Drift series = %v, want %v", got, want)
+	}
+}
@@ -1,6 +1,8 @@
 package common
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
@@ -16,10 +18,47 @@ var (
 	KnativeServingUpG  = knativeUp.WithLabelValues("serving_status")
 	KnativeEventingUpG = knativeUp.WithLabelValues("eventing_status")
 	KnativeKafkaUpG    = knativeUp.WithLabelValues("kafka_status")
+
+	knativeReconcilePaused = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "knative_reconcile_paused",
+			Help: "Reports if the reconciliation of a Knative component is paused",
+		},
+		[]string{"type"},
+	)
+	KnativeServingPausedG  = knativeReconcilePaused.WithLabelValues("serving_status")
+	KnativeEventingPausedG = knativeReconcilePaused.WithLabelValues("eventing_status")
+	KnativeKafkaPausedG    = knativeReconcilePaused.WithLabelValues("kafka_status")
+
+	componentDeploymentReady = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "knative_component_deployment_ready",
+			Help: "Reports if a deployment of a Knative component is ready",
+		},
+		[]string{"component", "namespace", "deployment"},
+	)
+
+	reconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "serverless_operator_reconcile_duration_seconds",
+			Help:    "Duration of the reconciliations of a Knative component",
+			Buckets: prometheus.ExponentialBuckets(0.01, 2, 14),
+		},
+		[]string{"component", "result"},
+	)
+
+	lastSuccessfulReconcile = &sinceCollector{
+		desc: prometheus.NewDesc(
+			"serverless_operator_seconds_since_last_successful_reconcile",
+			"Seconds since the last successful reconciliation of a Knative component, or since its first reconciliation if none succeeded yet",
+			[]string{"component"}, nil),
+		times: map[string]time.Time{},
+		now:   time.Now,
+	}
 )
 
 func init() {
 	// Register custom metrics with the global prometheus registry
-	metrics.Registry.MustRegister(knativeUp)
+	metrics.Registry.MustRegister(knativeUp, knativeReconcilePaused, componentDeploymentReady, reconcileDuration, lastSuccessfulReconcile)
 	knativeUp.DeleteLabelValues()
 }
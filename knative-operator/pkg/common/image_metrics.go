@@ -0,0 +1,55 @@
+package common
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	imageInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "serverless_operator_image_info",
+			Help: "Images used by the containers of a Knative component",
+		},
+		[]string{"component", "namespace", "kind", "workload", "container", "image", "pinned", "resolved"},
+	)
+
+	// reportedImages keeps the series reported per component, so they can be removed once they
+	// are no longer part of the inventory.
+	reportedImages   = map[string][]prometheus.Labels{}
+	reportedImagesMu sync.Mutex
+)
+
+func init() {
+	// Register custom metrics with the global prometheus registry
+	metrics.Registry.MustRegister(imageInfo)
+}
+
+// observeImages replaces the series of the given component with its current inventory.
+func observeImages(component string, images []ContainerImage) {
+	reportedImagesMu.Lock()
+	defer reportedImagesMu.Unlock()
+
+	for _, labels := range reportedImages[component] {
+		imageInfo.Delete(labels)
+	}
+	reported := make([]prometheus.Labels, 0, len(images))
+	for _, image := range images {
+		labels := prometheus.Labels{
+			"component": component,
+			"namespace": image.Namespace,
+			"kind":      image.Kind,
+			"workload":  image.Workload,
+			"container": image.Container,
+			"image":     image.Image,
+			"pinned":    strconv.FormatBool(image.Pinned),
+			"resolved":  strconv.FormatBool(image.Resolved),
+		}
+		imageInfo.With(labels).Set(1)
+		reported = append(reported, labels)
+	}
+	reportedImages[component] = reported
+}
@@ -0,0 +1,185 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ImagesPinned reflects whether all images of a component have been set by the operator and are
+	// pinned by digest. It's informational and doesn't affect the Ready condition.
+	ImagesPinned apis.ConditionType = "ImagesPinned"
+
+	// ImageInventoryStatusAnnotation is the status annotation holding the image inventory of a component as JSON.
+	ImageInventoryStatusAnnotation = "serverless.openshift.io/image-inventory"
+
+	// maxReportedImages limits the images listed in the condition message.
+	maxReportedImages = 5
+)
+
+// digestRegexp matches image references pinned by digest, e.g. "quay.io/foo/bar@sha256:<hex>".
+var digestRegexp = regexp.MustCompile(`@[a-z0-9]+([+._-][a-z0-9]+)*:[a-fA-F0-9]{32,}$`)
+
+// ContainerImage is an entry of the image inventory of a component.
+type ContainerImage struct {
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Workload  string `json:"workload"`
+	Container string `json:"container"`
+	Image     string `json:"image"`
+	// Pinned is true if the image is referenced by digest.
+	Pinned bool `json:"pinned"`
+	// Resolved is true if the image has been set from the images configured for the operator.
+	Resolved bool `json:"resolved"`
+}
+
+func (i ContainerImage) String() string {
+	return fmt.Sprintf("%s/%s/%s (%s)", i.Namespace, i.Workload, i.Container, i.Image)
+}
+
+// ImageOverrides returns the image configured for a container of a workload, if any.
+type ImageOverrides func(workload, container string) (string, bool)
+
+// ContainerImageOverrides looks images up in an override map as built by BuildImageOverrideMapFromEnviron,
+// preferring "workload/container" over "container" keys.
+func ContainerImageOverrides(overrideMap map[string]string) ImageOverrides {
+	return func(workload, container string) (string, bool) {
+		if image, ok := overrideMap[workload+"/"+container]; ok {
+			return image, true
+		}
+		image, ok := overrideMap[container]
+		return image, ok
+	}
+}
+
+// IsDigestPinned returns true if the image is referenced by digest rather than by tag.
+func IsDigestPinned(image string) bool {
+	return digestRegexp.MatchString(image)
+}
+
+// PodSpecImages returns the inventory of the containers in the given pod spec.
+func PodSpecImages(namespace, kind, workload string, spec *corev1.PodSpec, overrides ImageOverrides) []ContainerImage {
+	var images []ContainerImage
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for _, c := range containers {
+			override, ok := overrides(workload, c.Name)
+			images = append(images, ContainerImage{
+				Namespace: namespace,
+				Kind:      kind,
+				Workload:  workload,
+				Container: c.Name,
+				Image:     c.Image,
+				Pinned:    IsDigestPinned(c.Image),
+				Resolved:  ok && override == c.Image,
+			})
+		}
+	}
+	return images
+}
+
+// DeploymentImages returns the inventory of the given deployments.
+func DeploymentImages(deployments []appsv1.Deployment, overrides ImageOverrides) []ContainerImage {
+	var images []ContainerImage
+	for i := range deployments {
+		d := &deployments[i]
+		images = append(images, PodSpecImages(d.Namespace, "Deployment", d.Name, &d.Spec.Template.Spec, overrides)...)
+	}
+	return images
+}
+
+// WorkloadImages returns the inventory of the live workloads of the given manifest resources, as
+// the workloads might have been changed since the manifest was applied. Workloads that don't exist
+// (yet) are skipped.
+func WorkloadImages(api client.Client, resources []unstructured.Unstructured, overrides ImageOverrides) ([]ContainerImage, error) {
+	var images []ContainerImage
+	for i := range resources {
+		u := &resources[i]
+		var obj runtime.Object
+		var spec *corev1.PodSpec
+		switch u.GetKind() {
+		case "Deployment":
+			d := &appsv1.Deployment{}
+			obj, spec = d, &d.Spec.Template.Spec
+		case "DaemonSet":
+			d := &appsv1.DaemonSet{}
+			obj, spec = d, &d.Spec.Template.Spec
+		case "StatefulSet":
+			s := &appsv1.StatefulSet{}
+			obj, spec = s, &s.Spec.Template.Spec
+		case "Job":
+			j := &batchv1.Job{}
+			obj, spec = j, &j.Spec.Template.Spec
+		default:
+			continue
+		}
+		key := client.ObjectKey{Namespace: u.GetNamespace(), Name: u.GetName()}
+		if err := api.Get(context.TODO(), key, obj); apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get %s %s: %w", u.GetKind(), key, err)
+		}
+		images = append(images, PodSpecImages(u.GetNamespace(), u.GetKind(), u.GetName(), spec, overrides)...)
+	}
+	return images, nil
+}
+
+// ReportImages publishes the image inventory of a component in the status and as metric and marks
+// the ImagesPinned condition False if any image is not set by the operator or not pinned by digest.
+func ReportImages(component string, conditions apis.ConditionManager, status *duckv1.Status, images []ContainerImage) error {
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].String() < images[j].String()
+	})
+
+	inventory, err := json.Marshal(images)
+	if err != nil {
+		return fmt.Errorf("failed to marshal image inventory: %w", err)
+	}
+	if status.Annotations == nil {
+		status.Annotations = map[string]string{}
+	}
+	status.Annotations[ImageInventoryStatusAnnotation] = string(inventory)
+	observeImages(component, images)
+
+	var unresolved, unpinned []string
+	for _, image := range images {
+		if !image.Resolved {
+			unresolved = append(unresolved, image.String())
+		}
+		if !image.Pinned {
+			unpinned = append(unpinned, image.String())
+		}
+	}
+	switch {
+	case len(unresolved) > 0:
+		conditions.MarkFalse(ImagesPinned, "UnresolvedImages",
+			"%d of %d images are not set by the operator: %s", len(unresolved), len(images), summarize(unresolved))
+	case len(unpinned) > 0:
+		conditions.MarkFalse(ImagesPinned, "UnpinnedImages",
+			"%d of %d images are not pinned by digest: %s", len(unpinned), len(images), summarize(unpinned))
+	default:
+		conditions.MarkTrue(ImagesPinned)
+	}
+	return nil
+}
+
+// summarize lists the first few of the given images.
+func summarize(images []string) string {
+	if len(images) <= maxReportedImages {
+		return strings.Join(images, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(images[:maxReportedImages], ", "), len(images)-maxReportedImages)
+}
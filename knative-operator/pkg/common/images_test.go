@@ -0,0 +1,145 @@
+package common_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/pkg/apis"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const pinned = "quay.io/openshift-knative/activator@sha256:0f3fbf0b6a0a4d0ed1bc0fd1cba2b5b8a29bd1e1f1b5a2e2ff6e0d1e3c6d5c43"
+
+func TestIsDigestPinned(t *testing.T) {
+	cases := map[string]bool{
+		pinned:                                true,
+		"quay.io/openshift-knative/activator": false,
+		"quay.io/openshift-knative/activator:v0.17.3": false,
+		"localhost:5000/activator":                    false,
+		"activator@sha256:abc":                        false,
+	}
+	for image, want := range cases {
+		if got := common.IsDigestPinned(image); got != want {
+			t.Errorf("IsDigestPinned(%q) = %v, want %v", image, got, want)
+		}
+	}
+}
+
+func TestReportImages(t *testing.T) {
+	deployment := func(name, image string) appsv1.Deployment {
+		return appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "knative-serving"},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: name, Image: image}}},
+				},
+			},
+		}
+	}
+
+	cases := []struct {
+		name       string
+		overrides  map[string]string
+		image      string
+		wantStatus corev1.ConditionStatus
+		wantReason string
+	}{{
+		name:       "pinned",
+		overrides:  map[string]string{"activator": pinned},
+		image:      pinned,
+		wantStatus: corev1.ConditionTrue,
+	}, {
+		name:       "deployment specific override",
+		overrides:  map[string]string{"activator/activator": pinned, "activator": "quay.io/other"},
+		image:      pinned,
+		wantStatus: corev1.ConditionTrue,
+	}, {
+		name:       "tag",
+		overrides:  map[string]string{"activator": "quay.io/openshift-knative/activator:v0.17.3"},
+		image:      "quay.io/openshift-knative/activator:v0.17.3",
+		wantStatus: corev1.ConditionFalse,
+		wantReason: "UnpinnedImages",
+	}, {
+		name:       "not overridden",
+		image:      pinned,
+		wantStatus: corev1.ConditionFalse,
+		wantReason: "UnresolvedImages",
+	}, {
+		name:       "override not rolled out",
+		overrides:  map[string]string{"activator": pinned},
+		image:      "gcr.io/knative-releases/activator",
+		wantStatus: corev1.ConditionFalse,
+		wantReason: "UnresolvedImages",
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ks := &servingv1alpha1.KnativeServing{}
+			conditions := apis.NewLivingConditionSet().Manage(&ks.Status)
+			images := common.DeploymentImages([]appsv1.Deployment{deployment("activator", c.image)},
+				common.ContainerImageOverrides(c.overrides))
+
+			if err := common.ReportImages("test", conditions, &ks.Status.Status, images); err != nil {
+				t.Fatalf("ReportImages() = %v", err)
+			}
+
+			cond := ks.Status.GetCondition(common.ImagesPinned)
+			if cond == nil || cond.Status != c.wantStatus || cond.Reason != c.wantReason {
+				t.Errorf("Condition %s = %v, want status %s and reason %q", common.ImagesPinned, cond, c.wantStatus, c.wantReason)
+			}
+
+			var inventory []common.ContainerImage
+			if err := json.Unmarshal([]byte(ks.Status.Annotations[common.ImageInventoryStatusAnnotation]), &inventory); err != nil {
+				t.Fatalf("Failed to parse inventory: %v", err)
+			}
+			if !cmp.Equal(inventory, images) {
+				t.Errorf("Inventory not as expected, diff: %s", cmp.Diff(images, inventory))
+			}
+		})
+	}
+}
+
+func TestWorkloadImages(t *testing.T) {
+	resource := func(kind, name string) unstructured.Unstructured {
+		u := unstructured.Unstructured{}
+		u.SetAPIVersion("apps/v1")
+		u.SetKind(kind)
+		u.SetNamespace("knative-eventing")
+		u.SetName(name)
+		return u
+	}
+	// The image of the live deployment was changed after the manifest was applied.
+	api := fake.NewFakeClient(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "knative-eventing", Name: "kafka-controller"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "controller", Image: "quay.io/changed/controller:latest"}},
+		}}},
+	})
+	overrides := common.ContainerImageOverrides(map[string]string{"controller": pinned})
+
+	images, err := common.WorkloadImages(api, []unstructured.Unstructured{
+		resource("Deployment", "kafka-controller"),
+		resource("Deployment", "not-created-yet"),
+		resource("ConfigMap", "config-kafka"),
+	}, overrides)
+	if err != nil {
+		t.Fatalf("WorkloadImages() = %v", err)
+	}
+	want := []common.ContainerImage{{
+		Namespace: "knative-eventing",
+		Kind:      "Deployment",
+		Workload:  "kafka-controller",
+		Container: "controller",
+		Image:     "quay.io/changed/controller:latest",
+	}}
+	if !cmp.Equal(images, want) {
+		t.Errorf("Images not as expected, diff: %s", cmp.Diff(want, images))
+	}
+}
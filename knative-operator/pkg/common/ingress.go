@@ -0,0 +1,106 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+const (
+	// IngressNamespaceAnnotation sets the namespace the ingress is deployed to on a KnativeServing.
+	// Defaults to "<serving-namespace>-ingress".
+	IngressNamespaceAnnotation = "serverless.openshift.io/ingress-namespace"
+	// IngressNamespaceLabelsAnnotation sets additional labels of the ingress namespace as JSON object.
+	IngressNamespaceLabelsAnnotation = "serverless.openshift.io/ingress-namespace-labels"
+	// IngressNamespaceAnnotationsAnnotation sets additional annotations of the ingress namespace as JSON object.
+	IngressNamespaceAnnotationsAnnotation = "serverless.openshift.io/ingress-namespace-annotations"
+	// AppliedIngressNamespacesAnnotation is maintained by the operator. It records the comma-separated
+	// ingress namespaces the ingress has been applied to, so they can be cleaned up after a change.
+	AppliedIngressNamespacesAnnotation = "serverless.openshift.io/applied-ingress-namespaces"
+)
+
+// IngressNamespace returns namespace where ingress is deployed.
+func IngressNamespace(ks *servingv1alpha1.KnativeServing) string {
+	if ns := ks.GetAnnotations()[IngressNamespaceAnnotation]; ns != "" {
+		return ns
+	}
+	return DefaultIngressNamespace(ks)
+}
+
+// DefaultIngressNamespace returns the namespace the ingress is deployed to if not set otherwise.
+func DefaultIngressNamespace(ks *servingv1alpha1.KnativeServing) string {
+	return ks.GetNamespace() + "-ingress"
+}
+
+// AppliedIngressNamespaces returns the ingress namespaces recorded on the KnativeServing. Instances
+// installed before the namespace was recorded are assumed to use the default namespace.
+func AppliedIngressNamespaces(ks *servingv1alpha1.KnativeServing) []string {
+	value, ok := ks.GetAnnotations()[AppliedIngressNamespacesAnnotation]
+	if !ok {
+		return []string{DefaultIngressNamespace(ks)}
+	}
+	var namespaces []string
+	for _, ns := range strings.Split(value, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces
+}
+
+// IngressNamespaceMetadata returns the additional labels and annotations of the ingress namespace.
+func IngressNamespaceMetadata(ks *servingv1alpha1.KnativeServing) (labels, annotations map[string]string, err error) {
+	if labels, err = jsonMap(ks, IngressNamespaceLabelsAnnotation); err != nil {
+		return nil, nil, err
+	}
+	if annotations, err = jsonMap(ks, IngressNamespaceAnnotationsAnnotation); err != nil {
+		return nil, nil, err
+	}
+	return labels, annotations, nil
+}
+
+// ValidateIngressNamespace validates the ingress namespace settings of the KnativeServing.
+func ValidateIngressNamespace(ks *servingv1alpha1.KnativeServing) error {
+	if ns, ok := ks.GetAnnotations()[IngressNamespaceAnnotation]; ok {
+		if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+			return fmt.Errorf("invalid %s %q: %s", IngressNamespaceAnnotation, ns, strings.Join(errs, ", "))
+		}
+		if ns == ks.GetNamespace() {
+			return fmt.Errorf("invalid %s %q: must differ from the KnativeServing namespace", IngressNamespaceAnnotation, ns)
+		}
+	}
+
+	labels, annotations, err := IngressNamespaceMetadata(ks)
+	if err != nil {
+		return err
+	}
+	for k, v := range labels {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return fmt.Errorf("invalid label key %q in %s: %s", k, IngressNamespaceLabelsAnnotation, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
+			return fmt.Errorf("invalid label value %q in %s: %s", v, IngressNamespaceLabelsAnnotation, strings.Join(errs, ", "))
+		}
+	}
+	for k := range annotations {
+		if errs := validation.IsQualifiedName(strings.ToLower(k)); len(errs) > 0 {
+			return fmt.Errorf("invalid annotation key %q in %s: %s", k, IngressNamespaceAnnotationsAnnotation, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+func jsonMap(ks *servingv1alpha1.KnativeServing, annotation string) (map[string]string, error) {
+	value, ok := ks.GetAnnotations()[annotation]
+	if !ok {
+		return nil, nil
+	}
+	result := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse %s as JSON object: %w", annotation, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,85 @@
+package common_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+func TestIngressNamespace(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		want        string
+		wantApplied []string
+		wantErr     bool
+	}{{
+		name:        "default",
+		want:        "knative-serving-ingress",
+		wantApplied: []string{"knative-serving-ingress"},
+	}, {
+		name: "custom namespace",
+		annotations: map[string]string{
+			common.IngressNamespaceAnnotation:         "ingress",
+			common.AppliedIngressNamespacesAnnotation: "knative-serving-ingress, ingress",
+		},
+		want:        "ingress",
+		wantApplied: []string{"knative-serving-ingress", "ingress"},
+	}, {
+		name:        "invalid namespace",
+		annotations: map[string]string{common.IngressNamespaceAnnotation: "Ingress_Namespace"},
+		want:        "Ingress_Namespace",
+		wantApplied: []string{"knative-serving-ingress"},
+		wantErr:     true,
+	}, {
+		name:        "serving namespace",
+		annotations: map[string]string{common.IngressNamespaceAnnotation: "knative-serving"},
+		want:        "knative-serving",
+		wantApplied: []string{"knative-serving-ingress"},
+		wantErr:     true,
+	}, {
+		name: "metadata",
+		annotations: map[string]string{
+			common.IngressNamespaceLabelsAnnotation:      `{"team": "networking"}`,
+			common.IngressNamespaceAnnotationsAnnotation: `{"openshift.io/node-selector": "infra=true"}`,
+		},
+		want:        "knative-serving-ingress",
+		wantApplied: []string{"knative-serving-ingress"},
+	}, {
+		name:        "invalid label value",
+		annotations: map[string]string{common.IngressNamespaceLabelsAnnotation: `{"team": "not a value"}`},
+		want:        "knative-serving-ingress",
+		wantApplied: []string{"knative-serving-ingress"},
+		wantErr:     true,
+	}, {
+		name:        "malformed annotations",
+		annotations: map[string]string{common.IngressNamespaceAnnotationsAnnotation: "team=networking"},
+		want:        "knative-serving-ingress",
+		wantApplied: []string{"knative-serving-ingress"},
+		wantErr:     true,
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ks := &servingv1alpha1.KnativeServing{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "knative-serving",
+					Namespace:   "knative-serving",
+					Annotations: c.annotations,
+				},
+			}
+			if got := common.IngressNamespace(ks); got != c.want {
+				t.Errorf("IngressNamespace() = %q, want %q", got, c.want)
+			}
+			if got := common.AppliedIngressNamespaces(ks); !cmp.Equal(got, c.wantApplied) {
+				t.Errorf("AppliedIngressNamespaces() = %v, want %v", got, c.wantApplied)
+			}
+			if err := common.ValidateIngressNamespace(ks); (err != nil) != c.wantErr {
+				t.Errorf("ValidateIngressNamespace() = %v, wantErr %v", err, c.wantErr)
+			}
+		})
+	}
+}
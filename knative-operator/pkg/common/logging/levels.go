@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// ConfigMapName is the name of the ConfigMap in the operator namespace configuring the log levels.
+	ConfigMapName = "serverless-operator-logging"
+	// LevelKeyPrefix prefixes the ConfigMap keys setting the level of a named logger, e.g.
+	// "loglevel.controller: debug". The level applies to the logger and the loggers derived from it,
+	// unless they have a level of their own.
+	LevelKeyPrefix = "loglevel."
+)
+
+// Levels holds the levels of the named loggers of the operator and can be changed at runtime.
+type Levels struct {
+	defaultLevel zapcore.Level
+
+	mu     sync.RWMutex
+	levels map[string]zapcore.Level
+	min    zapcore.Level
+}
+
+// DefaultLevels are the levels used by the loggers of the operator.
+var DefaultLevels = NewLevels(zapcore.InfoLevel)
+
+// NewLevels returns Levels using the given level for all loggers without a level of their own.
+func NewLevels(defaultLevel zapcore.Level) *Levels {
+	return &Levels{defaultLevel: defaultLevel, min: defaultLevel}
+}
+
+// Enabled returns true if any logger is enabled at the given level. It implements zapcore.LevelEnabler.
+func (l *Levels) Enabled(lvl zapcore.Level) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return lvl >= l.min
+}
+
+// EnabledFor returns true if the logger of the given name is enabled at the given level. Names
+// are the dot separated names of zap, e.g. "knative.openshift.controller.mf", and the most
+// specific configured name component determines the level.
+func (l *Levels) EnabledFor(name string, lvl zapcore.Level) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	components := strings.Split(name, ".")
+	for i := len(components) - 1; i >= 0; i-- {
+		if level, ok := l.levels[components[i]]; ok {
+			return lvl >= level
+		}
+	}
+	return lvl >= l.defaultLevel
+}
+
+// Update replaces the levels with the ones configured in the given ConfigMap data. Invalid levels
+// are rejected as a whole and leave the current levels untouched.
+func (l *Levels) Update(data map[string]string) error {
+	levels := map[string]zapcore.Level{}
+	min := l.defaultLevel
+	var invalid []string
+	for key, value := range data {
+		if !strings.HasPrefix(key, LevelKeyPrefix) {
+			continue
+		}
+		var level zapcore.Level
+		if err := level.UnmarshalText([]byte(strings.TrimSpace(value))); err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s: %q", key, value))
+			continue
+		}
+		levels[strings.TrimPrefix(key, LevelKeyPrefix)] = level
+		if level < min {
+			min = level
+		}
+	}
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return fmt.Errorf("invalid log levels %s", strings.Join(invalid, ", "))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.levels = levels
+	l.min = min
+	return nil
+}
+
+// Core wraps a zap core to only log the entries enabled for the name of their logger. It is meant
+// to be used with zap.WrapCore.
+func (l *Levels) Core(core zapcore.Core) zapcore.Core {
+	return &levelCore{Core: core, levels: l}
+}
+
+type levelCore struct {
+	zapcore.Core
+	levels *Levels
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.levels.EnabledFor(ent.LoggerName, ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLevels(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel)
+	if err := levels.Update(map[string]string{
+		"loglevel.controller": "debug",
+		"loglevel.mf":         "error",
+		"unrelated":           "value",
+	}); err != nil {
+		t.Fatalf("Update() = %v", err)
+	}
+
+	cases := []struct {
+		name  string
+		level zapcore.Level
+		want  bool
+	}{
+		{"knative.openshift.controller", zapcore.DebugLevel, true},
+		{"knative.openshift.controller.knativekafka", zapcore.DebugLevel, true},
+		{"knative.openshift.controller.mf", zapcore.InfoLevel, false},
+		{"knative.openshift.controller.mf", zapcore.ErrorLevel, true},
+		{"knative.openshift.kourier", zapcore.DebugLevel, false},
+		{"knative.openshift.kourier", zapcore.InfoLevel, true},
+		{"", zapcore.InfoLevel, true},
+	}
+	for _, c := range cases {
+		if got := levels.EnabledFor(c.name, c.level); got != c.want {
+			t.Errorf("EnabledFor(%q, %s) = %v, want %v", c.name, c.level, got, c.want)
+		}
+	}
+	if !levels.Enabled(zapcore.DebugLevel) {
+		t.Error("Enabled(debug) = false, want true as the controller logs at debug")
+	}
+
+	if err := levels.Update(map[string]string{"loglevel.kourier": "verbose"}); err == nil {
+		t.Error("Update() = nil, want an error for an invalid level")
+	}
+	if !levels.EnabledFor("knative.openshift.controller", zapcore.DebugLevel) {
+		t.Error("Invalid update changed the levels")
+	}
+
+	if err := levels.Update(nil); err != nil {
+		t.Fatalf("Update() = %v", err)
+	}
+	if levels.Enabled(zapcore.DebugLevel) {
+		t.Error("Enabled(debug) = true after reset, want false")
+	}
+}
+
+func TestCore(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel)
+	if err := levels.Update(map[string]string{"loglevel.controller": "debug"}); err != nil {
+		t.Fatalf("Update() = %v", err)
+	}
+	var buf bytes.Buffer
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&buf), levels)
+	logger := zap.New(levels.Core(core)).Named("knative").Named("openshift")
+
+	logger.Named("controller").With(zap.String("key", "value")).Debug("logged")
+	logger.Named("kourier").Debug("dropped")
+	logger.Named("kourier").Info("logged")
+
+	if got := strings.Count(buf.String(), `"msg":"logged"`); got != 2 {
+		t.Errorf("Got %d logged entries, want 2", got)
+	}
+	if strings.Contains(buf.String(), "dropped") {
+		t.Errorf("Got dropped entry: %s", buf.String())
+	}
+}
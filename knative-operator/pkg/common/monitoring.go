@@ -4,14 +4,22 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
 	mfclient "github.com/manifestival/controller-runtime-client"
 	mf "github.com/manifestival/manifestival"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -20,12 +28,42 @@ const (
 	operatorDeploymentNameEnvKey = "DEPLOYMENT_NAME"
 	// service monitor created successfully when monitoringLabel added to namespace
 	monitoringLabel = "openshift.io/cluster-monitoring"
-	rolePath        = "deploy/role_service_monitor.yaml"
-	TestRolePath    = "TEST_ROLE_PATH"
+	// monitoringLabelAnnotation marks the namespaces the operator added the monitoringLabel to
+	monitoringLabelAnnotation = "serverless.openshift.io/cluster-monitoring-label"
+	// prometheusRoleName is the name of the Role and RoleBinding in rolePath
+	prometheusRoleName = "knative-serving-prometheus-k8s"
+	rolePath           = "deploy/role_service_monitor.yaml"
+	TestRolePath       = "TEST_ROLE_PATH"
+
+	// MonitoringModeEnvKey selects how the metrics of user namespaces are collected
+	MonitoringModeEnvKey = "MONITORING_MODE"
+	// MonitoringModeCluster collects the metrics of all namespaces with the platform monitoring
+	// stack, by labeling the namespaces for cluster monitoring. This is the default.
+	MonitoringModeCluster = "cluster"
+	// MonitoringModeUserWorkload leaves the metrics of user namespaces to OpenShift user-workload
+	// monitoring. Platform namespaces are still labeled for cluster monitoring. The labels the
+	// operator added to user namespaces are removed: the ones marked with monitoringLabelAnnotation,
+	// and the ones added by operator versions without the annotation, told apart by the
+	// knative-serving-prometheus-k8s Role the operator created for a source adapter alongside.
+	MonitoringModeUserWorkload = "user-workload"
 )
 
+// platformNamespacePrefixes are the prefixes of the namespaces monitored by the platform
+// monitoring stack in any monitoring mode, besides the namespaces of the Knative components.
+var platformNamespacePrefixes = []string{"openshift-", "kube-"}
+
+// SetupMonitoringRequirements enables the platform monitoring stack to collect the metrics of the
+// namespace of the given instance. Namespaces left to user-workload monitoring are cleaned up of
+// the requirements instead, see UsesUserWorkloadMonitoring.
 func SetupMonitoringRequirements(api client.Client, instance mf.Owner) error {
-	err := addMonitoringLabelToNamespace(instance.GetNamespace(), api)
+	userWorkload, err := UsesUserWorkloadMonitoring(api, instance.GetNamespace())
+	if err != nil {
+		return err
+	}
+	if userWorkload {
+		return removeMonitoringRequirements(api, instance.GetNamespace())
+	}
+	err = addMonitoringLabelToNamespace(instance.GetNamespace(), api)
 	if err != nil {
 		return err
 	}
@@ -68,29 +106,205 @@ func getRolePath() string {
 	return rolePath
 }
 
+// monitoringMode returns the configured monitoring mode.
+func monitoringMode() string {
+	switch mode := os.Getenv(MonitoringModeEnvKey); mode {
+	case "", MonitoringModeCluster:
+		return MonitoringModeCluster
+	case MonitoringModeUserWorkload:
+		return mode
+	default:
+		log.Info("Ignoring unknown monitoring mode", "mode", mode, "default", MonitoringModeCluster)
+		return MonitoringModeCluster
+	}
+}
+
+// UsesUserWorkloadMonitoring returns true if the metrics of the given namespace are collected by
+// OpenShift user-workload monitoring rather than the platform monitoring stack.
+func UsesUserWorkloadMonitoring(api client.Client, namespace string) (bool, error) {
+	if monitoringMode() != MonitoringModeUserWorkload {
+		return false, nil
+	}
+	platform, err := platformNamespaces(api)
+	if err != nil {
+		return false, err
+	}
+	return !platform.Has(namespace), nil
+}
+
+// platformNamespaces returns the namespaces monitored by the platform monitoring stack besides the
+// ones matching platformNamespacePrefixes: the namespaces of the operator and of the Knative
+// components, including the ingress namespaces.
+func platformNamespaces(api client.Client) (sets.String, error) {
+	namespaces := sets.NewString("openshift", os.Getenv(NamespaceEnvKey))
+	servings := &servingv1alpha1.KnativeServingList{}
+	if err := listInstances(api, servings); err != nil {
+		return nil, err
+	}
+	for i := range servings.Items {
+		ks := &servings.Items[i]
+		namespaces.Insert(ks.Namespace, IngressNamespace(ks))
+		namespaces.Insert(AppliedIngressNamespaces(ks)...)
+	}
+	eventings := &servingv1alpha1.KnativeEventingList{}
+	if err := listInstances(api, eventings); err != nil {
+		return nil, err
+	}
+	for _, ke := range eventings.Items {
+		namespaces.Insert(ke.Namespace)
+	}
+	return namespaces, nil
+}
+
+// listInstances lists the instances of a Knative component, if it's installed.
+func listInstances(api client.Client, list runtime.Object) error {
+	if err := api.List(context.TODO(), list); err != nil && !meta.IsNoMatchError(err) {
+		return fmt.Errorf("failed to list the Knative instances: %w", err)
+	}
+	return nil
+}
+
+func isPlatformNamespace(namespace string, platform sets.String) bool {
+	if platform.Has(namespace) {
+		return true
+	}
+	for _, prefix := range platformNamespacePrefixes {
+		if strings.HasPrefix(namespace, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// MigrateToUserWorkloadMonitoring removes the monitoring requirements the operator previously
+// set up in user namespaces, if they're left to user-workload monitoring. User-workload
+// monitoring ignores namespaces labeled for cluster monitoring.
+func MigrateToUserWorkloadMonitoring(api client.Client) error {
+	if monitoringMode() != MonitoringModeUserWorkload {
+		return nil
+	}
+	platform, err := platformNamespaces(api)
+	if err != nil {
+		return err
+	}
+	namespaces := &v1.NamespaceList{}
+	if err := api.List(context.TODO(), namespaces, client.MatchingLabels{monitoringLabel: "true"}); err != nil {
+		return err
+	}
+	for _, ns := range namespaces.Items {
+		if isPlatformNamespace(ns.Name, platform) {
+			continue
+		}
+		if err := removeMonitoringRequirements(api, ns.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func addMonitoringLabelToNamespace(namespace string, api client.Client) error {
 	ns := &v1.Namespace{}
 	if err := api.Get(context.TODO(), client.ObjectKey{Name: namespace}, ns); err != nil {
 		return err
 	}
+	if ns.Labels[monitoringLabel] == "true" {
+		return nil
+	}
 	if ns.Labels == nil {
 		ns.Labels = map[string]string{}
 	}
 	ns.Labels[monitoringLabel] = "true"
+	// Remember the label was added by the operator, so that it's only ever removed if so.
+	if ns.Annotations == nil {
+		ns.Annotations = map[string]string{}
+	}
+	ns.Annotations[monitoringLabelAnnotation] = "true"
 	if err := api.Update(context.TODO(), ns); err != nil {
 		return fmt.Errorf("could not add label %q to namespace %q: %w", monitoringLabel, namespace, err)
 	}
 	return nil
 }
 
-func createRoleAndRoleBinding(instance mf.Owner, namespace, path string, client client.Client) error {
+// removeMonitoringRequirements removes the monitoring label from the given namespace, along with
+// the Role and RoleBinding for the platform Prometheus, if the operator added it. Namespaces
+// labeled otherwise are kept monitored by the platform monitoring stack.
+func removeMonitoringRequirements(api client.Client, namespace string) error {
+	ns := &v1.Namespace{}
+	if err := api.Get(context.TODO(), client.ObjectKey{Name: namespace}, ns); err != nil {
+		return err
+	}
+	if _, labeled := ns.Labels[monitoringLabel]; labeled {
+		added, err := labeledByOperator(api, ns)
+		if err != nil {
+			return err
+		}
+		if !added {
+			log.Info("Keeping the cluster monitoring label not added by the operator, remove it along with the Role "+
+				prometheusRoleName+" to leave the namespace to user-workload monitoring", "namespace", namespace)
+			return nil
+		}
+		delete(ns.Labels, monitoringLabel)
+		delete(ns.Annotations, monitoringLabelAnnotation)
+		if err := api.Update(context.TODO(), ns); err != nil {
+			return fmt.Errorf("could not remove label %q from namespace %q: %w", monitoringLabel, namespace, err)
+		}
+		log.Info("Removed the cluster monitoring label, leaving the namespace to user-workload monitoring", "namespace", namespace)
+	}
+	for _, obj := range []runtime.Object{
+		&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: prometheusRoleName}},
+		&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: prometheusRoleName}},
+	} {
+		if err := api.Delete(context.TODO(), obj); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// labeledByOperator returns true if the operator added the monitoring label to the namespace.
+// Operator versions not marking the label with monitoringLabelAnnotation are recognized by the
+// Role they created along with the label for a source adapter, which owns it.
+func labeledByOperator(api client.Client, ns *v1.Namespace) (bool, error) {
+	if ns.Annotations[monitoringLabelAnnotation] == "true" {
+		return true, nil
+	}
+	role := &rbacv1.Role{}
+	if err := api.Get(context.TODO(), client.ObjectKey{Namespace: ns.Name, Name: prometheusRoleName}, role); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	for _, ref := range role.OwnerReferences {
+		if ref.APIVersion == appsv1.SchemeGroupVersion.String() && (ref.Kind == "Deployment" || ref.Kind == "StatefulSet") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MonitoringRoleManifest returns the Role and RoleBinding letting the platform monitoring stack
+// scrape the namespace of the given instance.
+func MonitoringRoleManifest(api client.Client, instance mf.Owner) (mf.Manifest, error) {
+	return roleManifest(instance, instance.GetNamespace(), getRolePath(), api)
+}
+
+func roleManifest(instance mf.Owner, namespace, path string, client client.Client) (mf.Manifest, error) {
 	manifest, err := mf.NewManifest(path, mf.UseClient(mfclient.NewClient(client)))
 	if err != nil {
-		return fmt.Errorf("unable to create role and roleBinding ServiceMonitor install manifest: %w", err)
+		return mf.Manifest{}, fmt.Errorf("unable to create role and roleBinding ServiceMonitor install manifest: %w", err)
 	}
 	transforms := []mf.Transformer{mf.InjectOwner(instance), injectNameSpace(namespace)}
 	if manifest, err = manifest.Transform(transforms...); err != nil {
-		return fmt.Errorf("unable to transform role and roleBinding serviceMonitor manifest: %w", err)
+		return mf.Manifest{}, fmt.Errorf("unable to transform role and roleBinding serviceMonitor manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+func createRoleAndRoleBinding(instance mf.Owner, namespace, path string, client client.Client) error {
+	manifest, err := roleManifest(instance, namespace, path, client)
+	if err != nil {
+		return err
 	}
 	if err := manifest.Apply(); err != nil {
 		return fmt.Errorf("unable to create role and roleBinding for ServiceMonitor %w", err)
@@ -8,8 +8,11 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	operatorv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
@@ -75,3 +78,123 @@ func TestSetupMonitoringRequirements(t *testing.T) {
 		t.Errorf("got %q, want %q", sub.Namespace, "openshift-monitoring")
 	}
 }
+
+func TestUserWorkloadMonitoring(t *testing.T) {
+	os.Setenv(MonitoringModeEnvKey, MonitoringModeUserWorkload)
+	defer os.Unsetenv(MonitoringModeEnvKey)
+
+	// The namespace was labeled by the user and got the Role for a source, like from operator versions
+	// not marking the label, which can't be told apart.
+	userRole := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "user-role", Labels: map[string]string{monitoringLabel: "true"}}}
+	role := &v1.Role{ObjectMeta: metav1.ObjectMeta{Namespace: "user-role", Name: prometheusRoleName}}
+	binding := &v1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Namespace: "user-role", Name: prometheusRoleName}}
+	// The namespace was labeled by the user.
+	userLabeled := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "user-labeled", Labels: map[string]string{monitoringLabel: "true"}}}
+	// The namespace was labeled by an operator version not marking the label, along with the Role
+	// for a source adapter, owning it.
+	legacy := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "legacy", Labels: map[string]string{monitoringLabel: "true"}}}
+	adapter := []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "source"}}
+	legacyRole := &v1.Role{ObjectMeta: metav1.ObjectMeta{Namespace: "legacy", Name: prometheusRoleName, OwnerReferences: adapter}}
+	legacyBinding := &v1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Namespace: "legacy", Name: prometheusRoleName, OwnerReferences: adapter}}
+	tenant := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant"}}
+	// A user namespace, despite its prefix.
+	demo := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "knative-demo"}}
+	eventing := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "knative-eventing"}}
+	instance := &operatorv1alpha1.KnativeEventing{ObjectMeta: metav1.ObjectMeta{Namespace: "knative-eventing", Name: "knative-eventing"}}
+	cl := fake.NewFakeClient(userRole, role, binding, userLabeled, legacy, legacyRole, legacyBinding, tenant, demo, eventing, instance, &operatorNamespace)
+
+	if err := MigrateToUserWorkloadMonitoring(cl); err != nil {
+		t.Fatalf("MigrateToUserWorkloadMonitoring() = %v", err)
+	}
+	for _, source := range []appsv1.Deployment{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: "source"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "knative-demo", Name: "source"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "knative-eventing", Name: "source"}},
+	} {
+		if err := SetupMonitoringRequirements(cl, &source); err != nil {
+			t.Fatalf("SetupMonitoringRequirements() = %v", err)
+		}
+	}
+
+	for name, want := range map[string]bool{
+		"user-role":        true,
+		"user-labeled":     true,
+		"legacy":           false,
+		"tenant":           false,
+		"knative-demo":     false,
+		"knative-eventing": true,
+	} {
+		ns := &corev1.Namespace{}
+		if err := cl.Get(context.TODO(), client.ObjectKey{Name: name}, ns); err != nil {
+			t.Fatalf("Failed to get namespace %s: %v", name, err)
+		}
+		if got := ns.Labels[monitoringLabel] == "true"; got != want {
+			t.Errorf("Namespace %s labeled for cluster monitoring = %v, want %v", name, got, want)
+		}
+	}
+	for _, obj := range []runtime.Object{&v1.Role{}, &v1.RoleBinding{}} {
+		if err := cl.Get(context.TODO(), client.ObjectKey{Namespace: "user-role", Name: prometheusRoleName}, obj); err != nil {
+			t.Errorf("Failed to get the %T of the namespace labeled by the user: %v", obj, err)
+		}
+		if err := cl.Get(context.TODO(), client.ObjectKey{Namespace: "knative-eventing", Name: prometheusRoleName}, obj); err != nil {
+			t.Errorf("Failed to get the %T of the platform namespace: %v", obj, err)
+		}
+		if err := cl.Get(context.TODO(), client.ObjectKey{Namespace: "legacy", Name: prometheusRoleName}, obj); !apierrors.IsNotFound(err) {
+			t.Errorf("Got %v, want the %T added by the previous operator version to be removed", err, obj)
+		}
+	}
+
+	// Labels added by the operator are removed when switching to user-workload monitoring.
+	os.Setenv(MonitoringModeEnvKey, MonitoringModeCluster)
+	if err := SetupMonitoringRequirements(cl, &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: "source"}}); err != nil {
+		t.Fatalf("SetupMonitoringRequirements() = %v", err)
+	}
+	os.Setenv(MonitoringModeEnvKey, MonitoringModeUserWorkload)
+	if err := MigrateToUserWorkloadMonitoring(cl); err != nil {
+		t.Fatalf("MigrateToUserWorkloadMonitoring() = %v", err)
+	}
+	ns := &corev1.Namespace{}
+	if err := cl.Get(context.TODO(), client.ObjectKey{Name: "tenant"}, ns); err != nil {
+		t.Fatal(err)
+	}
+	if _, labeled := ns.Labels[monitoringLabel]; labeled {
+		t.Errorf("Got labels %v, want the label added by the operator to be removed", ns.Labels)
+	}
+	for _, obj := range []runtime.Object{&v1.Role{}, &v1.RoleBinding{}} {
+		if err := cl.Get(context.TODO(), client.ObjectKey{Namespace: "tenant", Name: prometheusRoleName}, obj); !apierrors.IsNotFound(err) {
+			t.Errorf("Got %v, want the %T added by the operator to be removed", err, obj)
+		}
+	}
+}
+
+func TestServingServiceMonitorsNotServed(t *testing.T) {
+	defer os.Unsetenv(TestServingServiceMonitorPath)
+	os.Setenv(TestServingServiceMonitorPath, "../../deploy/resources/serving-service-monitors.yaml")
+	// ServiceMonitors are not known to the client, as if the cluster didn't serve them.
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := operatorv1alpha1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	instance := &operatorv1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{Name: "knative-serving", Namespace: "knative-serving"},
+	}
+	cl := fake.NewFakeClientWithScheme(s, instance, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "knative-serving"}})
+
+	if err := SetupServingServiceMonitors(cl, instance); err != nil {
+		t.Fatalf("SetupServingServiceMonitors() = %v", err)
+	}
+	key := client.ObjectKey{Namespace: "knative-serving", Name: "activator-sm-service"}
+	if err := cl.Get(context.TODO(), key, &corev1.Service{}); err != nil {
+		t.Errorf("Failed to get Service applied without the ServiceMonitors: %v", err)
+	}
+
+	if err := RemoveServingServiceMonitors(cl, instance); err != nil {
+		t.Fatalf("RemoveServingServiceMonitors() = %v", err)
+	}
+	if err := cl.Get(context.TODO(), key, &corev1.Service{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Get() = %v, want the Service deleted", err)
+	}
+}
@@ -0,0 +1,39 @@
+package common
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+)
+
+const (
+	// ReconcileAnnotation set to ReconcilePaused on an instance makes the operator skip everything
+	// but status reporting for it, e.g. to hand-edit managed resources while debugging.
+	ReconcileAnnotation = "serverless.openshift.io/reconcile"
+	// ReconcilePaused is the value of ReconcileAnnotation pausing the reconciliation.
+	ReconcilePaused = "paused"
+
+	// ReconcileActive reflects whether the operator reconciles the instance. It's informational and
+	// doesn't affect the Ready condition.
+	ReconcileActive apis.ConditionType = "ReconcileActive"
+)
+
+// IsReconcilePaused returns true if the reconciliation of the instance is paused.
+func IsReconcilePaused(instance metav1.Object) bool {
+	return instance.GetAnnotations()[ReconcileAnnotation] == ReconcilePaused
+}
+
+// ReportReconcilePaused reflects whether the reconciliation of the instance is paused in the
+// ReconcileActive condition and the given gauge and returns true if it is.
+func ReportReconcilePaused(instance metav1.Object, conditions apis.ConditionManager, gauge prometheus.Gauge) bool {
+	if !IsReconcilePaused(instance) {
+		conditions.MarkTrue(ReconcileActive)
+		gauge.Set(0)
+		return false
+	}
+	log.Info("Reconciliation is paused", "namespace", instance.GetNamespace(), "name", instance.GetName())
+	conditions.MarkFalse(ReconcileActive, "Paused",
+		"Reconciliation is paused by the %s annotation, remove it to resume", ReconcileAnnotation)
+	gauge.Set(1)
+	return true
+}
@@ -0,0 +1,196 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common/tracing"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/apis"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type resultType int
+
+const (
+	resultDone resultType = iota
+	resultRequeue
+	resultError
+	resultFatal
+)
+
+// StageResult is the outcome of a single Stage of a Pipeline.
+type StageResult struct {
+	typ          resultType
+	requeueAfter time.Duration
+	reason       string
+	err          error
+}
+
+// Done signals that the stage completed and the pipeline continues with the next stage.
+func Done() StageResult {
+	return StageResult{typ: resultDone}
+}
+
+// RequeueAfter signals that the stage is not done yet, for example because it is waiting for
+// deployments to become ready. The pipeline stops and the request is requeued after the given
+// duration without error backoff.
+func RequeueAfter(after time.Duration, reason, messageFormat string, messageA ...interface{}) StageResult {
+	return StageResult{
+		typ:          resultRequeue,
+		requeueAfter: after,
+		reason:       reason,
+		err:          fmt.Errorf(messageFormat, messageA...),
+	}
+}
+
+// Error signals a transient failure. The pipeline stops and the error is returned, so the
+// request is retried with error backoff. A nil error is the same as Done.
+func Error(err error) StageResult {
+	if err == nil {
+		return Done()
+	}
+	return StageResult{typ: resultError, err: err}
+}
+
+// Fatal signals a failure that retrying won't fix, like an invalid configuration. The pipeline
+// stops and the request is not retried until one of the watched resources changes.
+func Fatal(reason string, err error) StageResult {
+	return StageResult{typ: resultFatal, reason: reason, err: err}
+}
+
+// Stage is a single step of a Pipeline.
+type Stage struct {
+	// Name identifies the stage in logs and metrics.
+	Name string
+	// Condition reflects the outcome of the stage in the status. Optional.
+	Condition apis.ConditionType
+	// Reason of the Condition if the stage fails and the result doesn't carry a reason.
+	Reason string
+	// Run executes the stage.
+	Run func() StageResult
+}
+
+// Pipeline runs a sequence of stages for a component until one of them doesn't complete.
+type Pipeline struct {
+	// Component identifies the pipeline in logs and metrics, e.g. "serving".
+	Component string
+	// Conditions manages the conditions of the stages. Optional.
+	Conditions apis.ConditionManager
+	// Trace records a span per stage. Optional.
+	Trace *tracing.Reconciliation
+}
+
+// Run runs the given stages in order and translates the result of the first stage that
+// doesn't complete into the result of the reconciliation.
+func (p Pipeline) Run(stages ...Stage) (reconcile.Result, error) {
+	for _, stage := range stages {
+		start := time.Now()
+		endSpan := p.Trace.StartStage(stage.Name)
+		result := stage.Run()
+		stageDuration.WithLabelValues(p.Component, stage.Name).Observe(time.Since(start).Seconds())
+		if result.typ == resultError || result.typ == resultFatal {
+			endSpan(result.err)
+		} else {
+			endSpan(nil)
+		}
+
+		reason := result.reason
+		if reason == "" {
+			reason = stage.Reason
+		}
+		if result.typ != resultDone {
+			failureReason := reason
+			if failureReason == "" {
+				failureReason = "Unknown"
+			}
+			stageErrors.WithLabelValues(p.Component, stage.Name, resultLabel(result.typ), failureReason).Inc()
+		}
+		if p.Conditions != nil && stage.Condition != "" {
+			if result.typ == resultDone {
+				p.Conditions.MarkTrue(stage.Condition)
+			} else {
+				p.Conditions.MarkFalse(stage.Condition, reason, "%v", result.err)
+			}
+		}
+
+		switch result.typ {
+		case resultRequeue:
+			Log.Info("Stage not done yet, requeueing", "component", p.Component, "stage", stage.Name,
+				"after", result.requeueAfter, "reason", result.err.Error())
+			return reconcile.Result{RequeueAfter: result.requeueAfter}, nil
+		case resultError:
+			return reconcile.Result{}, fmt.Errorf("stage %s failed: %w", stage.Name, result.err)
+		case resultFatal:
+			Log.Error(result.err, "Stage failed permanently", "component", p.Component, "stage", stage.Name)
+			return reconcile.Result{}, nil
+		}
+	}
+	return reconcile.Result{}, nil
+}
+
+func resultLabel(typ resultType) string {
+	switch typ {
+	case resultRequeue:
+		return "requeue"
+	case resultFatal:
+		return "fatal"
+	default:
+		return "error"
+	}
+}
+
+// EnsureFinalizer adds the given finalizer to the object and updates it, if it's not there yet.
+func EnsureFinalizer(api client.Client, obj controllerutil.Object, finalizer string) error {
+	if controllerutil.ContainsFinalizer(obj, finalizer) {
+		return nil
+	}
+	Log.Info("Adding finalizer", "finalizer", finalizer)
+	controllerutil.AddFinalizer(obj, finalizer)
+	return api.Update(context.TODO(), obj)
+}
+
+// Finalize runs the given cleanup for the object, if it still has the given finalizer, and
+// removes the finalizer afterwards. As the cleanup might take a while, the object is refetched
+// before removing the finalizer.
+func Finalize(api client.Client, obj controllerutil.Object, finalizer string, cleanup func() error) error {
+	if !controllerutil.ContainsFinalizer(obj, finalizer) {
+		Log.Info("Finalizer has already been removed, nothing to do", "finalizer", finalizer)
+		return nil
+	}
+
+	Log.Info("Running cleanup logic", "finalizer", finalizer)
+	if err := cleanup(); err != nil {
+		return err
+	}
+	return RemoveFinalizer(api, obj, finalizer)
+}
+
+// RemoveFinalizer refetches the object and removes the given finalizer from it.
+func RemoveFinalizer(api client.Client, obj controllerutil.Object, finalizer string) error {
+	refetched, ok := obj.DeepCopyObject().(controllerutil.Object)
+	if !ok {
+		return fmt.Errorf("failed to copy %T", obj)
+	}
+	key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	if err := api.Get(context.TODO(), key, refetched); err != nil {
+		return fmt.Errorf("failed to refetch %s: %w", kindOf(obj), err)
+	}
+
+	controllerutil.RemoveFinalizer(refetched, finalizer)
+	if err := api.Update(context.TODO(), refetched); err != nil {
+		return fmt.Errorf("failed to update %s with removed finalizer: %w", kindOf(obj), err)
+	}
+	return nil
+}
+
+func kindOf(obj runtime.Object) string {
+	if kind := obj.GetObjectKind().GroupVersionKind().Kind; kind != "" {
+		return kind
+	}
+	return fmt.Sprintf("%T", obj)
+}
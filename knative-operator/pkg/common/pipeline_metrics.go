@@ -0,0 +1,29 @@
+package common
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	stageDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "serverless_operator_stage_duration_seconds",
+			Help:    "Duration of a reconcile stage of a Knative component",
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 12),
+		},
+		[]string{"component", "stage"},
+	)
+	stageErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "serverless_operator_stage_failures_total",
+			Help: "Number of reconcile stages of a Knative component that did not complete",
+		},
+		[]string{"component", "stage", "result", "reason"},
+	)
+)
+
+func init() {
+	// Register custom metrics with the global prometheus registry
+	metrics.Registry.MustRegister(stageDuration, stageErrors)
+}
@@ -0,0 +1,175 @@
+package common_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/pkg/apis"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	firstReady  apis.ConditionType = "FirstReady"
+	secondReady apis.ConditionType = "SecondReady"
+)
+
+func TestPipeline(t *testing.T) {
+	errStage := errors.New("stage failed")
+
+	tests := []struct {
+		name       string
+		second     common.StageResult
+		wantResult reconcile.Result
+		wantErr    bool
+		wantRan    []string
+		wantCond   corev1.ConditionStatus
+		wantReason string
+	}{{
+		name:     "done",
+		second:   common.Done(),
+		wantRan:  []string{"first", "second", "third"},
+		wantCond: corev1.ConditionTrue,
+	}, {
+		name:       "requeue",
+		second:     common.RequeueAfter(10*time.Second, "NotReady", "waiting for %s", "deployments"),
+		wantResult: reconcile.Result{RequeueAfter: 10 * time.Second},
+		wantRan:    []string{"first", "second"},
+		wantCond:   corev1.ConditionFalse,
+		wantReason: "NotReady",
+	}, {
+		name:       "error",
+		second:     common.Error(errStage),
+		wantErr:    true,
+		wantRan:    []string{"first", "second"},
+		wantCond:   corev1.ConditionFalse,
+		wantReason: "Failed",
+	}, {
+		name:       "fatal",
+		second:     common.Fatal("Invalid", errStage),
+		wantRan:    []string{"first", "second"},
+		wantCond:   corev1.ConditionFalse,
+		wantReason: "Invalid",
+	}, {
+		name:     "nil error",
+		second:   common.Error(nil),
+		wantRan:  []string{"first", "second", "third"},
+		wantCond: corev1.ConditionTrue,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status := &servingv1alpha1.KnativeServingStatus{}
+			pipeline := common.Pipeline{
+				Component:  "test",
+				Conditions: apis.NewLivingConditionSet().Manage(status),
+			}
+
+			var ran []string
+			stage := func(name string, condition apis.ConditionType, result common.StageResult) common.Stage {
+				return common.Stage{Name: name, Condition: condition, Reason: "Failed", Run: func() common.StageResult {
+					ran = append(ran, name)
+					return result
+				}}
+			}
+
+			result, err := pipeline.Run(
+				stage("first", firstReady, common.Done()),
+				stage("second", secondReady, test.second),
+				stage("third", "", common.Done()),
+			)
+			if (err != nil) != test.wantErr {
+				t.Errorf("Run() = %v, wantErr %v", err, test.wantErr)
+			}
+			if result != test.wantResult {
+				t.Errorf("Run() = %v, want %v", result, test.wantResult)
+			}
+			if !cmp.Equal(ran, test.wantRan) {
+				t.Errorf("Stages not as expected, diff: %s", cmp.Diff(test.wantRan, ran))
+			}
+			if cond := status.GetCondition(firstReady); cond == nil || cond.Status != corev1.ConditionTrue {
+				t.Errorf("Condition %s = %v, want True", firstReady, cond)
+			}
+			cond := status.GetCondition(secondReady)
+			if cond == nil || cond.Status != test.wantCond || cond.Reason != test.wantReason {
+				t.Errorf("Condition %s = %v, want status %s and reason %q", secondReady, cond, test.wantCond, test.wantReason)
+			}
+		})
+	}
+}
+
+func TestFinalizer(t *testing.T) {
+	const finalizer = "test-finalizer"
+	ks := &servingv1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "knative-serving",
+			Namespace:  "knative-serving",
+			Finalizers: []string{"other"},
+		},
+	}
+	key := types.NamespacedName{Namespace: ks.Namespace, Name: ks.Name}
+	servingv1alpha1.AddToScheme(scheme.Scheme)
+	cl := fake.NewFakeClientWithScheme(scheme.Scheme, ks.DeepCopy())
+
+	instance := &servingv1alpha1.KnativeServing{}
+	if err := cl.Get(context.TODO(), key, instance); err != nil {
+		t.Fatalf("get: (%v)", err)
+	}
+	if err := common.EnsureFinalizer(cl, instance, finalizer); err != nil {
+		t.Fatalf("EnsureFinalizer() = %v", err)
+	}
+	// Adding it twice is a no-op.
+	if err := common.EnsureFinalizer(cl, instance, finalizer); err != nil {
+		t.Fatalf("EnsureFinalizer() = %v", err)
+	}
+	assertFinalizers(t, cl, key, []string{"other", finalizer})
+
+	cleanups := 0
+	cleanup := func() error {
+		cleanups++
+		return nil
+	}
+
+	// A failing cleanup keeps the finalizer.
+	if err := common.Finalize(cl, instance, finalizer, func() error { return errors.New("cleanup failed") }); err == nil {
+		t.Fatal("Finalize() = nil, want an error")
+	}
+	assertFinalizers(t, cl, key, []string{"other", finalizer})
+
+	if err := common.Finalize(cl, instance, finalizer, cleanup); err != nil {
+		t.Fatalf("Finalize() = %v", err)
+	}
+	assertFinalizers(t, cl, key, []string{"other"})
+
+	// The cleanup doesn't run if the finalizer is gone.
+	if err := cl.Get(context.TODO(), key, instance); err != nil {
+		t.Fatalf("get: (%v)", err)
+	}
+	if err := common.Finalize(cl, instance, finalizer, cleanup); err != nil {
+		t.Fatalf("Finalize() = %v", err)
+	}
+	if cleanups != 1 {
+		t.Errorf("Cleanup ran %d times, want 1", cleanups)
+	}
+}
+
+func assertFinalizers(t *testing.T, cl client.Client, key types.NamespacedName, want []string) {
+	t.Helper()
+	got := &servingv1alpha1.KnativeServing{}
+	if err := cl.Get(context.TODO(), key, got); err != nil {
+		t.Fatalf("get: (%v)", err)
+	}
+	if !cmp.Equal(got.Finalizers, want) {
+		t.Errorf("Finalizers not as expected, diff: %s", cmp.Diff(want, got.Finalizers))
+	}
+}
@@ -0,0 +1,76 @@
+package common
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// ObservedReconciler wraps the reconciler of a Knative component to observe the duration and the
+// outcome of its reconciliations. A reconciliation succeeds if it neither fails nor is requeued.
+func ObservedReconciler(component string, r reconcile.Reconciler) reconcile.Reconciler {
+	return &observedReconciler{component: component, Reconciler: r}
+}
+
+type observedReconciler struct {
+	reconcile.Reconciler
+	component string
+}
+
+func (r *observedReconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	start := time.Now()
+	lastSuccessfulReconcile.init(r.component, start)
+
+	result, err := r.Reconciler.Reconcile(request)
+
+	outcome := "success"
+	switch {
+	case err != nil:
+		outcome = "error"
+	case result.Requeue || result.RequeueAfter > 0:
+		outcome = "requeue"
+	default:
+		lastSuccessfulReconcile.set(r.component, time.Now())
+	}
+	reconcileDuration.WithLabelValues(r.component, outcome).Observe(time.Since(start).Seconds())
+	return result, err
+}
+
+// sinceCollector reports the time passed since the recorded time per component at scrape time.
+type sinceCollector struct {
+	desc *prometheus.Desc
+
+	mu    sync.Mutex
+	times map[string]time.Time
+	now   func() time.Time
+}
+
+// init records the given time, unless a time has already been recorded for the component.
+func (c *sinceCollector) init(component string, t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.times[component]; !ok {
+		c.times[component] = t
+	}
+}
+
+func (c *sinceCollector) set(component string, t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.times[component] = t
+}
+
+func (c *sinceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *sinceCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for component, t := range c.times {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, now.Sub(t).Seconds(), component)
+	}
+}
@@ -0,0 +1,83 @@
+package common
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type stubReconciler struct {
+	result reconcile.Result
+	err    error
+}
+
+func (r *stubReconciler) Reconcile(reconcile.Request) (reconcile.Result, error) {
+	return r.result, r.err
+}
+
+func TestObservedReconciler(t *testing.T) {
+	now := time.Now()
+	lastSuccessfulReconcile.now = func() time.Time { return now }
+	defer func() { lastSuccessfulReconcile.now = time.Now }()
+	delete(lastSuccessfulReconcile.times, "test")
+	for _, outcome := range []string{"error", "requeue", "success"} {
+		reconcileDuration.DeleteLabelValues("test", outcome)
+	}
+
+	stub := &stubReconciler{err: errors.New("failed")}
+	r := ObservedReconciler("test", stub)
+
+	r.Reconcile(reconcile.Request{})
+	first := lastSuccessfulReconcile.times["test"]
+	now = now.Add(time.Minute)
+	if got, want := sinceLastSuccess(t, "test"), now.Sub(first).Seconds(); got != want {
+		t.Errorf("Seconds since last success = %v, want %v since the first reconciliation", got, want)
+	}
+
+	stub.err = nil
+	stub.result = reconcile.Result{RequeueAfter: time.Second}
+	r.Reconcile(reconcile.Request{})
+	if got := lastSuccessfulReconcile.times["test"]; got != first {
+		t.Errorf("Requeued reconciliation recorded as success at %v", got)
+	}
+
+	stub.result = reconcile.Result{}
+	r.Reconcile(reconcile.Request{})
+	now = time.Now().Add(30 * time.Second)
+	if got := sinceLastSuccess(t, "test"); got < 30 || got >= 60 {
+		t.Errorf("Seconds since last success = %v, want about 30", got)
+	}
+
+	for outcome, want := range map[string]uint64{"error": 1, "requeue": 1, "success": 1} {
+		m := &dto.Metric{}
+		if err := reconcileDuration.WithLabelValues("test", outcome).(prometheus.Histogram).Write(m); err != nil {
+			t.Fatal(err)
+		}
+		if got := m.GetHistogram().GetSampleCount(); got != want {
+			t.Errorf("Reconciliations with result %s = %d, want %d", outcome, got, want)
+		}
+	}
+}
+
+func sinceLastSuccess(t *testing.T, component string) float64 {
+	ch := make(chan prometheus.Metric, 10)
+	lastSuccessfulReconcile.Collect(ch)
+	close(ch)
+	for metric := range ch {
+		m := &dto.Metric{}
+		if err := metric.Write(m); err != nil {
+			t.Fatal(err)
+		}
+		for _, label := range m.GetLabel() {
+			if label.GetName() == "component" && label.GetValue() == component {
+				return m.GetGauge().GetValue()
+			}
+		}
+	}
+	t.Fatalf("No series for component %s", component)
+	return 0
+}
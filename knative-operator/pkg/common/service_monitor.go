@@ -11,17 +11,18 @@ import (
 	mf "github.com/manifestival/manifestival"
 	kubemetrics "github.com/operator-framework/operator-sdk/pkg/kube-metrics"
 	"github.com/operator-framework/operator-sdk/pkg/metrics"
-	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
-	eventingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	operatorv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
 	kmeta "knative.dev/pkg/kmeta"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -30,6 +31,7 @@ const (
 	EventingBrokerServiceMonitorPath     = "deploy/resources/broker-service-monitors.yaml"
 	EventingSourceServiceMonitorPath     = "deploy/resources/source-service-monitor.yaml"
 	EventingSourcePath                   = "deploy/resources/source-service.yaml"
+	ServingServiceMonitorPath            = "deploy/resources/serving-service-monitors.yaml"
 	SourceLabel                          = "eventing.knative.dev/source"
 	SourceNameLabel                      = "eventing.knative.dev/sourceName"
 	SourceRoleLabel                      = "sources.knative.dev/role"
@@ -37,6 +39,7 @@ const (
 	TestMonitor                          = "TEST_MONITOR"
 	TestSourceServiceMonitorPath         = "TEST_SOURCE_SERVICE_MONITOR_PATH"
 	TestSourceServicePath                = "TEST_SOURCE_SERVICE_PATH"
+	TestServingServiceMonitorPath        = "TEST_SERVING_SERVICE_MONITOR_PATH"
 )
 
 func SetupServerlessOperatorServiceMonitor(cfg *rest.Config, api client.Client, metricsPort int32, metricsHost string, operatorMetricsPort int32) error {
@@ -115,31 +118,97 @@ func serveCRMetrics(cfg *rest.Config, metricsHost string, operatorMetricsPort in
 	return nil
 }
 
-func SetupEventingBrokerServiceMonitors(client client.Client, instance *eventingv1alpha1.KnativeEventing) error {
+func SetupEventingBrokerServiceMonitors(client client.Client, instance *operatorv1alpha1.KnativeEventing) error {
+	manifest, err := EventingBrokerServiceMonitorsManifest(client, instance)
+	if err != nil {
+		return err
+	}
+	if err := manifest.Apply(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// EventingBrokerServiceMonitorsManifest returns the ServiceMonitors scraping the broker filter and
+// ingress of the given instance.
+func EventingBrokerServiceMonitorsManifest(client client.Client, instance *operatorv1alpha1.KnativeEventing) (mf.Manifest, error) {
 	manifest, err := mf.NewManifest(getMonitorPath(TestEventingBrokerServiceMonitorPath, EventingBrokerServiceMonitorPath), mf.UseClient(mfclient.NewClient(client)))
 	if err != nil {
-		return fmt.Errorf("unable to parse broker service monitors: %w", err)
+		return mf.Manifest{}, fmt.Errorf("unable to parse broker service monitors: %w", err)
 	}
 	transforms := []mf.Transformer{mf.InjectOwner(instance), mf.InjectNamespace(instance.Namespace)}
 	if manifest, err = manifest.Transform(transforms...); err != nil {
-		return fmt.Errorf("unable to transform broker service monitors manifest: %w", err)
+		return mf.Manifest{}, fmt.Errorf("unable to transform broker service monitors manifest: %w", err)
 	}
-	if err := manifest.Apply(); err != nil {
+	return manifest, nil
+}
+
+// SetupServingServiceMonitors installs the Services and ServiceMonitors exposing the metrics of
+// the Serving control plane, i.e. the activator, autoscaler, controller and webhook, and enables
+// the cluster monitoring to scrape them.
+func SetupServingServiceMonitors(client client.Client, instance *operatorv1alpha1.KnativeServing) error {
+	if err := SetupMonitoringRequirements(client, instance); err != nil {
+		return err
+	}
+	manifest, err := ServingServiceMonitorsManifest(client, instance)
+	if err != nil {
+		return err
+	}
+	// The Services are applied even if the cluster doesn't serve ServiceMonitors.
+	if err := manifest.Filter(mf.Not(mf.ByKind(monitoringv1.ServiceMonitorsKind))).Apply(); err != nil {
+		return err
+	}
+	if err := manifest.Filter(mf.ByKind(monitoringv1.ServiceMonitorsKind)).Apply(); err != nil {
+		if meta.IsNoMatchError(err) {
+			log.Info("ServiceMonitors not served by the cluster, skipping the Serving service monitors")
+			return nil
+		}
 		return err
 	}
 	return nil
 }
 
-func SetupSourceServiceMonitor(client client.Client, instance *appsv1.Deployment) error {
-	labels := instance.Spec.Selector.MatchLabels
+// RemoveServingServiceMonitors deletes the Services and ServiceMonitors of the Serving control plane.
+func RemoveServingServiceMonitors(client client.Client, instance *operatorv1alpha1.KnativeServing) error {
+	manifest, err := ServingServiceMonitorsManifest(client, instance)
+	if err != nil {
+		return err
+	}
+	if err := manifest.Filter(mf.ByKind(monitoringv1.ServiceMonitorsKind)).Delete(); err != nil && !meta.IsNoMatchError(err) {
+		return err
+	}
+	return manifest.Filter(mf.Not(mf.ByKind(monitoringv1.ServiceMonitorsKind))).Delete()
+}
+
+// ServingServiceMonitorsManifest returns the Services and ServiceMonitors exposing the metrics of
+// the Serving control plane of the given instance.
+func ServingServiceMonitorsManifest(client client.Client, instance *operatorv1alpha1.KnativeServing) (mf.Manifest, error) {
+	manifest, err := mf.NewManifest(getMonitorPath(TestServingServiceMonitorPath, ServingServiceMonitorPath), mf.UseClient(mfclient.NewClient(client)))
+	if err != nil {
+		return mf.Manifest{}, fmt.Errorf("unable to parse serving service monitors: %w", err)
+	}
+	transforms := []mf.Transformer{mf.InjectOwner(instance), mf.InjectNamespace(instance.Namespace)}
+	if manifest, err = manifest.Transform(transforms...); err != nil {
+		return mf.Manifest{}, fmt.Errorf("unable to transform serving service monitors manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// IsSourceAdapter returns true if the given selector labels select the pods of a source adapter.
+func IsSourceAdapter(labels map[string]string) bool {
+	return labels[SourceLabel] != "" && (labels[SourceNameLabel] != "" || labels[SourceRoleLabel] != "")
+}
 
+// SetupSourceServiceMonitor creates or updates the Service and ServiceMonitor exposing the metrics
+// of a source adapter, selecting its pods by the given labels.
+func SetupSourceServiceMonitor(client client.Client, instance mf.Owner, labels map[string]string) error {
 	clientOptions := mf.UseClient(mfclient.NewClient(client))
-	// create service for the deployment
+	// create service for the adapter
 	manifest, err := mf.NewManifest(getMonitorPath(TestSourceServicePath, EventingSourcePath), clientOptions)
 	if err != nil {
 		return fmt.Errorf("unable to parse source service manifest: %w", err)
 	}
-	transforms := []mf.Transformer{updateService(labels, instance.Name), mf.InjectOwner(instance), mf.InjectNamespace(instance.Namespace)}
+	transforms := []mf.Transformer{updateService(labels, instance.GetName()), mf.InjectOwner(instance), mf.InjectNamespace(instance.GetNamespace())}
 	if manifest, err = manifest.Transform(transforms...); err != nil {
 		return fmt.Errorf("unable to transform source service manifest: %w", err)
 	}
@@ -149,15 +218,16 @@ func SetupSourceServiceMonitor(client client.Client, instance *appsv1.Deployment
 
 	// get service back, needed for the UID and setting owner refs
 	srv := &v1.Service{}
-	if err := client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, srv); err != nil {
+	if err := client.Get(context.TODO(), types.NamespacedName{Name: instance.GetName(), Namespace: instance.GetNamespace()}, srv); err != nil {
 		return err
 	}
+	srv.SetGroupVersionKind(v1.SchemeGroupVersion.WithKind("Service"))
 	// create service monitor for source
 	manifest, err = mf.NewManifest(getMonitorPath(TestSourceServiceMonitorPath, EventingSourceServiceMonitorPath), clientOptions)
 	if err != nil {
 		return fmt.Errorf("unable to parse source service monitor manifest: %w", err)
 	}
-	transforms = []mf.Transformer{updateServiceMonitor(labels, instance.Name), mf.InjectOwner(srv), mf.InjectNamespace(instance.Namespace)}
+	transforms = []mf.Transformer{updateServiceMonitor(labels, instance.GetName()), mf.InjectOwner(srv), mf.InjectNamespace(instance.GetNamespace())}
 	if manifest, err = manifest.Transform(transforms...); err != nil {
 		return fmt.Errorf("unable to transform source service monitor manifest: %w", err)
 	}
@@ -167,6 +237,40 @@ func SetupSourceServiceMonitor(client client.Client, instance *appsv1.Deployment
 	return nil
 }
 
+// RemoveSourceServiceMonitor deletes the Service and ServiceMonitor set up for the source adapter
+// of the given name and kind, if any. Resources of the same name not set up for a source adapter,
+// or set up for an adapter of another kind, are kept.
+func RemoveSourceServiceMonitor(api client.Client, namespace, name, kind string) error {
+	key := types.NamespacedName{Namespace: namespace, Name: name}
+	svc := &v1.Service{}
+	err := api.Get(context.TODO(), key, svc)
+	if err != nil && !apierrs.IsNotFound(err) {
+		return err
+	}
+	if owner := metav1.GetControllerOf(svc); err == nil && owner != nil && owner.Kind != kind {
+		return nil
+	}
+	for _, obj := range []runtime.Object{&monitoringv1.ServiceMonitor{}, &v1.Service{}} {
+		if err := api.Get(context.TODO(), key, obj); err != nil {
+			if apierrs.IsNotFound(err) || meta.IsNoMatchError(err) {
+				continue
+			}
+			return err
+		}
+		m, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+		if labels := m.GetLabels(); labels["name"] != name || !IsSourceAdapter(labels) {
+			continue
+		}
+		if err := api.Delete(context.TODO(), obj); err != nil && !apierrs.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
 func getMonitorPath(envVar string, defaultVal string) string {
 	path := os.Getenv(envVar)
 	if path == "" {
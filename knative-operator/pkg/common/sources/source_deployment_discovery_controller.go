@@ -3,10 +3,17 @@ package sources
 import (
 	"context"
 
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	mf "github.com/manifestival/manifestival"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
 	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -19,87 +26,192 @@ import (
 
 var log = common.Log.WithName("source-deployment-discovery-controller")
 
-// Add creates a new Controller and adds it to the Manager. The Manager will set fields on the Controller
-// and Start it when the Manager is Started.
+// adapterKinds are the kinds of workloads running source adapters, by controller name.
+var adapterKinds = map[string]schema.GroupVersionKind{
+	"source-deployment-discovery-controller":  v1.SchemeGroupVersion.WithKind("Deployment"),
+	"source-statefulset-discovery-controller": v1.SchemeGroupVersion.WithKind("StatefulSet"),
+}
+
+// Add creates a Controller per kind of source adapter workload and adds them to the Manager. The
+// Manager will set fields on the Controllers and Start them when the Manager is Started.
 func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+	for name, gvk := range adapterKinds {
+		if err := add(mgr, name, newReconciler(mgr, gvk)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileSourceDeployment{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+// newReconciler returns a new reconcile.Reconciler for the source adapters of the given kind
+func newReconciler(mgr manager.Manager, gvk schema.GroupVersionKind) *ReconcileSourceDeployment {
+	return &ReconcileSourceDeployment{client: mgr.GetClient(), scheme: mgr.GetScheme(), gvk: gvk}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, name string, r *ReconcileSourceDeployment) error {
 	// Create a new controller
-	c, err := controller.New("source-deployment-discovery-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(name, mgr, controller.Options{Reconciler: r})
 	if err != nil {
 		return err
 	}
+	obj, err := r.newObject()
+	if err != nil {
+		return err
+	}
+	if err := c.Watch(&source.Kind{Type: obj}, &handler.EnqueueRequestForObject{}, adapterPredicate{}); err != nil {
+		return err
+	}
 
-	// common function to enqueue reconcile requests for resources
-	enqueueRequests := handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
-		dep := obj.Object.(*v1.Deployment)
-		sourceLabel := dep.Spec.Selector.MatchLabels[common.SourceLabel]
-		sourceNameLabel := dep.Spec.Selector.MatchLabels[common.SourceNameLabel]
-		sourceRoleLabel := dep.Spec.Selector.MatchLabels[common.SourceRoleLabel]
-
-		if (sourceLabel != "" && sourceNameLabel != "") || (sourceLabel != "" && sourceRoleLabel != "") {
-			return []reconcile.Request{{
-				NamespacedName: types.NamespacedName{Namespace: obj.Meta.GetNamespace(), Name: obj.Meta.GetName()},
-			}}
-		}
-		return nil
-	})
-	err = c.Watch(&source.Kind{Type: &v1.Deployment{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: enqueueRequests}, skipDeletePredicate{}, skipUpdatePredicate{})
+	// Watch the Services and ServiceMonitors of the adapters to restore them. The Services are
+	// owned by the adapters, the ServiceMonitors by the Services of the same name.
+	err = c.Watch(&source.Kind{Type: &corev1.Service{}}, &handler.EnqueueRequestForOwner{OwnerType: obj, IsController: true}, monitoringPredicate{})
 	if err != nil {
 		return err
 	}
-	return nil
+	gvk := monitoringv1.SchemeGroupVersion.WithKind(monitoringv1.ServiceMonitorsKind)
+	if _, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+		if meta.IsNoMatchError(err) {
+			log.Info("ServiceMonitors not served by the cluster, not watching them")
+			return nil
+		}
+		return err
+	}
+	return c.Watch(&source.Kind{Type: &monitoringv1.ServiceMonitor{}},
+		&handler.EnqueueRequestForOwner{OwnerType: &corev1.Service{}, IsController: true}, monitoringPredicate{})
 }
 
 // blank assignment to verify that ReconcileSourceDeployment implements reconcile.Reconciler
 var _ reconcile.Reconciler = &ReconcileSourceDeployment{}
 
-// ReconcileSourceDeployment reconciles a source deployment object
+// ReconcileSourceDeployment reconciles the Service and ServiceMonitor of source adapters run by
+// Deployments or StatefulSets
 type ReconcileSourceDeployment struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
 	client client.Client
 	scheme *runtime.Scheme
+	// gvk is the kind of the workloads running the source adapters.
+	gvk schema.GroupVersionKind
 }
 
-// Reconcile reads that state of the cluster for an eventing source deployment
+// Reconcile reads that state of the cluster for an eventing source adapter. The Service and
+// ServiceMonitor are updated to the current labels of the adapter, and removed if the adapter
+// is gone or no longer a source adapter.
 func (r *ReconcileSourceDeployment) Reconcile(request reconcile.Request) (reconcile.Result, error) {
-	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
-	reqLogger.Info("Reconciling the source deployment, setting up a service/service monitor if required")
-	dep := &v1.Deployment{}
-	if err := r.client.Get(context.TODO(), request.NamespacedName, dep); err != nil {
-		// the deployment does not exist anymore do nothing
-		return reconcile.Result{}, nil
-	}
-	if err := common.SetupMonitoringRequirements(r.client, dep); err != nil {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name, "Kind", r.gvk.Kind)
+	reqLogger.Info("Reconciling the source adapter, setting up a service/service monitor if required")
+	obj, err := r.newObject()
+	if err != nil {
 		return reconcile.Result{}, err
 	}
-	if err := common.SetupSourceServiceMonitor(r.client, dep); err != nil {
+	if err := r.client.Get(context.TODO(), request.NamespacedName, obj); apierrors.IsNotFound(err) {
+		reqLogger.Info("Source adapter is gone, removing its service/service monitor")
+		return reconcile.Result{}, common.RemoveSourceServiceMonitor(r.client, request.Namespace, request.Name, r.gvk.Kind)
+	} else if err != nil {
+		return reconcile.Result{}, err
+	}
+	labels := adapterLabels(obj)
+	if !common.IsSourceAdapter(labels) {
+		return reconcile.Result{}, common.RemoveSourceServiceMonitor(r.client, request.Namespace, request.Name, r.gvk.Kind)
+	}
+
+	// Objects read from the cache lack their kind, which the owner references need.
+	obj.GetObjectKind().SetGroupVersionKind(r.gvk)
+	owner := obj.(mf.Owner)
+	if err := common.SetupMonitoringRequirements(r.client, owner); err != nil {
+		return reconcile.Result{}, err
+	}
+	if err := common.SetupSourceServiceMonitor(r.client, owner, labels); err != nil {
 		return reconcile.Result{}, err
 	}
 	return reconcile.Result{}, nil
 }
 
-type skipDeletePredicate struct {
+func (r *ReconcileSourceDeployment) newObject() (runtime.Object, error) {
+	return r.scheme.New(r.gvk)
+}
+
+// adapterLabels returns the labels of the pods of the given workload, which the Service selects
+// them by: the labels of the pod template, which include the immutable selector labels.
+func adapterLabels(obj runtime.Object) map[string]string {
+	var selector *metav1.LabelSelector
+	var template map[string]string
+	switch o := obj.(type) {
+	case *v1.Deployment:
+		selector, template = o.Spec.Selector, o.Spec.Template.Labels
+	case *v1.StatefulSet:
+		selector, template = o.Spec.Selector, o.Spec.Template.Labels
+	default:
+		return nil
+	}
+	labels := map[string]string{}
+	if selector != nil {
+		for k, v := range selector.MatchLabels {
+			labels[k] = v
+		}
+	}
+	for k, v := range template {
+		labels[k] = v
+	}
+	return labels
+}
+
+// workloadLabels returns the labels of the given workload.
+func workloadLabels(obj runtime.Object) map[string]string {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return nil
+	}
+	return m.GetLabels()
+}
+
+// adapterPredicate passes on the events of source adapters. Updates are passed on if the labels
+// of the workload or its pods changed, including to or from the labels of a source adapter, so
+// that the Service and ServiceMonitor follow them.
+type adapterPredicate struct {
 	predicate.Funcs
 }
 
-func (skipDeletePredicate) Delete(e event.DeleteEvent) bool {
-	return false
+func (adapterPredicate) Create(e event.CreateEvent) bool {
+	return common.IsSourceAdapter(adapterLabels(e.Object))
+}
+
+func (adapterPredicate) Update(e event.UpdateEvent) bool {
+	oldLabels, newLabels := adapterLabels(e.ObjectOld), adapterLabels(e.ObjectNew)
+	if !common.IsSourceAdapter(oldLabels) && !common.IsSourceAdapter(newLabels) {
+		return false
+	}
+	return !equality.Semantic.DeepEqual(oldLabels, newLabels) ||
+		!equality.Semantic.DeepEqual(workloadLabels(e.ObjectOld), workloadLabels(e.ObjectNew))
+}
+
+func (adapterPredicate) Delete(e event.DeleteEvent) bool {
+	return common.IsSourceAdapter(adapterLabels(e.Object))
 }
 
-type skipUpdatePredicate struct {
+func (adapterPredicate) Generic(e event.GenericEvent) bool {
+	return common.IsSourceAdapter(adapterLabels(e.Object))
+}
+
+// monitoringPredicate passes on the events of the Services and ServiceMonitors set up for source
+// adapters, so that they're restored if changed or deleted.
+type monitoringPredicate struct {
 	predicate.Funcs
 }
 
-func (skipUpdatePredicate) Update(e event.UpdateEvent) bool {
+func (monitoringPredicate) Create(event.CreateEvent) bool {
+	return false
+}
+
+func (monitoringPredicate) Update(e event.UpdateEvent) bool {
+	return common.IsSourceAdapter(e.MetaOld.GetLabels()) || common.IsSourceAdapter(e.MetaNew.GetLabels())
+}
+
+func (monitoringPredicate) Delete(e event.DeleteEvent) bool {
+	return common.IsSourceAdapter(e.Meta.GetLabels())
+}
+
+func (monitoringPredicate) Generic(event.GenericEvent) bool {
 	return false
 }
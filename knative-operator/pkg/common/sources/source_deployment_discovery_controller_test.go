@@ -10,11 +10,13 @@ import (
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
 	v1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -81,7 +83,7 @@ func TestSourceReconcile(t *testing.T) {
 	initObjs := []runtime.Object{&apiserversourceDeployment, &pingsourceDeployment, &defaultNamespace, &eventingNamespace}
 	cl := fake.NewFakeClient(initObjs...)
 
-	r := &ReconcileSourceDeployment{client: cl, scheme: scheme.Scheme}
+	r := &ReconcileSourceDeployment{client: cl, scheme: scheme.Scheme, gvk: v1.SchemeGroupVersion.WithKind("Deployment")}
 	// Reconcile for an api server source
 	if _, err := r.Reconcile(apiserverRequest); err != nil {
 		t.Fatalf("reconcile: (%v)", err)
@@ -126,3 +128,156 @@ func TestSourceReconcile(t *testing.T) {
 		t.Fatalf("got %q, want %q", smPing.Spec.Selector.MatchLabels["name"], "ping1")
 	}
 }
+
+// TestSourceLifecycle verifies that the monitoring resources follow the labels of source adapters
+// and are removed with them, for adapters run by StatefulSets as well.
+func TestSourceLifecycle(t *testing.T) {
+	adapter := &v1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kafka1",
+			Namespace: "default",
+		},
+		Spec: v1.StatefulSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					common.SourceLabel:     "kafka-source-controller",
+					common.SourceNameLabel: "kafka1",
+				},
+			},
+		},
+	}
+	unrelated := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "unrelated",
+			Namespace: "default",
+			Labels:    map[string]string{"name": "unrelated"},
+		},
+	}
+	cl := fake.NewFakeClient(adapter, unrelated, &defaultNamespace)
+	r := &ReconcileSourceDeployment{client: cl, scheme: scheme.Scheme, gvk: v1.SchemeGroupVersion.WithKind("StatefulSet")}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "kafka1"}}
+
+	if _, err := r.Reconcile(request); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	svc := &corev1.Service{}
+	if err := cl.Get(context.TODO(), request.NamespacedName, svc); err != nil {
+		t.Fatalf("get: (%v)", err)
+	}
+	if svc.Spec.Selector[common.SourceNameLabel] != "kafka1" {
+		t.Fatalf("got %q, want %q", svc.Spec.Selector[common.SourceNameLabel], "kafka1")
+	}
+	if len(svc.OwnerReferences) != 1 || svc.OwnerReferences[0].Kind != "StatefulSet" {
+		t.Fatalf("got owner references %v, want the StatefulSet", svc.OwnerReferences)
+	}
+	if err := cl.Get(context.TODO(), request.NamespacedName, &monitoringv1.ServiceMonitor{}); err != nil {
+		t.Fatalf("get: (%v)", err)
+	}
+
+	// Label changes of the pods are applied to the Service, the selector is immutable.
+	adapter.Spec.Template.Labels = map[string]string{
+		common.SourceLabel:     "kafka-source-controller",
+		common.SourceNameLabel: "kafka1",
+		common.SourceRoleLabel: "adapter",
+	}
+	if err := cl.Update(context.TODO(), adapter); err != nil {
+		t.Fatalf("update: (%v)", err)
+	}
+	if _, err := r.Reconcile(request); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	svc = &corev1.Service{}
+	if err := cl.Get(context.TODO(), request.NamespacedName, svc); err != nil {
+		t.Fatalf("get: (%v)", err)
+	}
+	if svc.Spec.Selector[common.SourceRoleLabel] != "adapter" || svc.Spec.Selector[common.SourceNameLabel] != "kafka1" {
+		t.Fatalf("got selector %v, want the changed labels", svc.Spec.Selector)
+	}
+
+	// The controller of Deployments keeps the resources of a StatefulSet of the same name.
+	deployments := &ReconcileSourceDeployment{client: cl, scheme: scheme.Scheme, gvk: v1.SchemeGroupVersion.WithKind("Deployment")}
+	if _, err := deployments.Reconcile(request); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if err := cl.Get(context.TODO(), request.NamespacedName, &corev1.Service{}); err != nil {
+		t.Fatalf("got %v, want the service of the StatefulSet to be kept", err)
+	}
+
+	// The resources are removed with the adapter, unrelated ones are kept.
+	if err := cl.Delete(context.TODO(), adapter); err != nil {
+		t.Fatalf("delete: (%v)", err)
+	}
+	for _, name := range []string{"kafka1", "unrelated"} {
+		if _, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: name}}); err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+	}
+	if err := cl.Get(context.TODO(), request.NamespacedName, &corev1.Service{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("got %v, want the service to be removed", err)
+	}
+	if err := cl.Get(context.TODO(), request.NamespacedName, &monitoringv1.ServiceMonitor{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("got %v, want the service monitor to be removed", err)
+	}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "unrelated"}, &corev1.Service{}); err != nil {
+		t.Fatalf("got %v, want the unrelated service to be kept", err)
+	}
+}
+
+func TestAdapterPredicate(t *testing.T) {
+	source := apiserversourceDeployment.DeepCopy()
+	other := source.DeepCopy()
+	other.Spec.Selector.MatchLabels = map[string]string{"app": "other"}
+	relabeled := source.DeepCopy()
+	relabeled.Spec.Template.Labels = map[string]string{common.SourceNameLabel: "api2"}
+	workloadRelabeled := source.DeepCopy()
+	workloadRelabeled.Labels = map[string]string{"app": "api"}
+
+	p := adapterPredicate{}
+	if !p.Create(event.CreateEvent{Object: source}) || p.Create(event.CreateEvent{Object: other}) {
+		t.Error("Create() should only pass on source adapters")
+	}
+	if !p.Delete(event.DeleteEvent{Object: source}) || p.Delete(event.DeleteEvent{Object: other}) {
+		t.Error("Delete() should only pass on source adapters")
+	}
+	if p.Update(event.UpdateEvent{ObjectOld: source, ObjectNew: source.DeepCopy()}) {
+		t.Error("Update() should skip unchanged labels")
+	}
+	if !p.Update(event.UpdateEvent{ObjectOld: source, ObjectNew: relabeled}) {
+		t.Error("Update() should pass on label changes of the pods")
+	}
+	if !p.Update(event.UpdateEvent{ObjectOld: source, ObjectNew: workloadRelabeled}) {
+		t.Error("Update() should pass on label changes of the workload")
+	}
+	if !p.Update(event.UpdateEvent{ObjectOld: source, ObjectNew: other}) {
+		t.Error("Update() should pass on adapters no longer being source adapters")
+	}
+	if p.Update(event.UpdateEvent{ObjectOld: other, ObjectNew: other.DeepCopy()}) {
+		t.Error("Update() should skip workloads which aren't source adapters")
+	}
+}
+
+func TestMonitoringPredicate(t *testing.T) {
+	adapterLabels := map[string]string{
+		common.SourceLabel:     "apiserver-source-controller",
+		common.SourceNameLabel: "api1",
+		"name":                 "api1",
+	}
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "api1", Namespace: "default", Labels: adapterLabels}}
+	changed := svc.DeepCopy()
+	changed.Labels = nil
+	other := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default", Labels: map[string]string{"name": "other"}}}
+
+	p := monitoringPredicate{}
+	if p.Create(event.CreateEvent{Meta: svc, Object: svc}) {
+		t.Error("Create() should skip the resources, which are created by the reconciler")
+	}
+	if !p.Update(event.UpdateEvent{MetaOld: svc, ObjectOld: svc, MetaNew: changed, ObjectNew: changed}) {
+		t.Error("Update() should pass on changes of the resources of source adapters")
+	}
+	if p.Update(event.UpdateEvent{MetaOld: other, ObjectOld: other, MetaNew: other, ObjectNew: other}) {
+		t.Error("Update() should skip other resources")
+	}
+	if !p.Delete(event.DeleteEvent{Meta: svc, Object: svc}) || p.Delete(event.DeleteEvent{Meta: other, Object: other}) {
+		t.Error("Delete() should only pass on the resources of source adapters")
+	}
+}
@@ -0,0 +1,85 @@
+package telemetry
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	operatorv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DisabledEnvKey opts the cluster out of telemetry if set to "true". It's honored by the
+	// telemetry of all components and disables the summary as well.
+	DisabledEnvKey = "TELEMETRY_DISABLED"
+	// SummaryIntervalEnvKey is the interval of the summary written for OpenShift Insights, e.g.
+	// "1h". No summary is written if it's not set.
+	SummaryIntervalEnvKey = "TELEMETRY_SUMMARY_INTERVAL"
+	// SummaryConfigMapName is the name of the ConfigMap in the operator namespace holding the
+	// summary, which OpenShift Insights collects.
+	SummaryConfigMapName = "serverless-telemetry-summary"
+)
+
+// Disabled returns true if the cluster opted out of telemetry.
+func Disabled() bool {
+	disabled, _ := strconv.ParseBool(os.Getenv(DisabledEnvKey))
+	return disabled
+}
+
+// SummaryInterval returns the interval of the summary, or 0 if no summary is written.
+func SummaryInterval() (time.Duration, error) {
+	interval := os.Getenv(SummaryIntervalEnvKey)
+	if interval == "" || Disabled() {
+		return 0, nil
+	}
+	return time.ParseDuration(interval)
+}
+
+// Summary returns the summary of the installed components and their resources, as the data of
+// the summary ConfigMap. The keys are:
+//   - "<component>.version" for the installed version of Serving and Eventing,
+//   - "kafka.channel.enabled" and "kafka.source.enabled" if KnativeKafka is installed,
+//   - "<type>.count" and "<type>.namespaces" for the counted resources of running telemetry,
+//   - "broker.class.<class>" for the brokers per class.
+func Summary(ctx context.Context, api client.Client) (map[string]string, error) {
+	data := map[string]string{}
+
+	servings := &operatorv1alpha1.KnativeServingList{}
+	if err := api.List(ctx, servings); err != nil {
+		return nil, err
+	}
+	for _, serving := range servings.Items {
+		data["serving.version"] = serving.Status.GetVersion()
+	}
+	eventings := &operatorv1alpha1.KnativeEventingList{}
+	if err := api.List(ctx, eventings); err != nil {
+		return nil, err
+	}
+	for _, eventing := range eventings.Items {
+		data["eventing.version"] = eventing.Status.GetVersion()
+	}
+	kafkas := &v1alpha1.KnativeKafkaList{}
+	if err := api.List(ctx, kafkas); err != nil {
+		return nil, err
+	}
+	for _, kafka := range kafkas.Items {
+		data["kafka.channel.enabled"] = strconv.FormatBool(kafka.Spec.Channel.Enabled)
+		data["kafka.source.enabled"] = strconv.FormatBool(kafka.Spec.Source.Enabled)
+	}
+
+	for _, c := range counters {
+		resources, namespaces, classes := c.counts()
+		data[c.typ+".count"] = strconv.Itoa(resources)
+		data[c.typ+".namespaces"] = strconv.Itoa(namespaces)
+		if c.class == nil {
+			continue
+		}
+		for class, count := range classes {
+			data[c.typ+".class."+class] = strconv.Itoa(count)
+		}
+	}
+	return data, nil
+}
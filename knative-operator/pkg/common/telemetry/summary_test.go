@@ -0,0 +1,95 @@
+package telemetry
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/eventing/pkg/apis/eventing"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	operatorv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSummaryInterval(t *testing.T) {
+	defer os.Unsetenv(SummaryIntervalEnvKey)
+	defer os.Unsetenv(DisabledEnvKey)
+
+	if interval, err := SummaryInterval(); err != nil || interval != 0 {
+		t.Errorf("SummaryInterval() = %v, %v, want no summary by default", interval, err)
+	}
+	os.Setenv(SummaryIntervalEnvKey, "1h")
+	if interval, err := SummaryInterval(); err != nil || interval != time.Hour {
+		t.Errorf("SummaryInterval() = %v, %v, want 1h", interval, err)
+	}
+	os.Setenv(DisabledEnvKey, "true")
+	if interval, err := SummaryInterval(); err != nil || interval != 0 {
+		t.Errorf("SummaryInterval() = %v, %v, want no summary if telemetry is disabled", interval, err)
+	}
+	os.Unsetenv(DisabledEnvKey)
+	os.Setenv(SummaryIntervalEnvKey, "hourly")
+	if _, err := SummaryInterval(); err == nil {
+		t.Error("SummaryInterval() = nil error, want an error for an invalid interval")
+	}
+}
+
+func TestSummary(t *testing.T) {
+	for _, c := range counters {
+		c.reset()
+	}
+	defer func() {
+		for _, c := range counters {
+			c.reset()
+		}
+	}()
+	counterFor(revision).add(revision)
+	counterFor(&eventingv1.Broker{}).add(&eventingv1.Broker{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   "ns",
+		Name:        "default",
+		Annotations: map[string]string{eventing.BrokerClassKey: "Kafka"},
+	}})
+
+	serving := &operatorv1alpha1.KnativeServing{ObjectMeta: metav1.ObjectMeta{Namespace: "knative-serving", Name: "knative-serving"}}
+	serving.Status.SetVersion("0.18.2")
+	kafka := &v1alpha1.KnativeKafka{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "knative-eventing", Name: "knative-kafka"},
+		Spec:       v1alpha1.KnativeKafkaSpec{Channel: v1alpha1.Channel{Enabled: true}},
+	}
+	api := fake.NewFakeClient(serving, kafka)
+
+	data, err := Summary(context.TODO(), api)
+	if err != nil {
+		t.Fatalf("Summary() = %v", err)
+	}
+	want := map[string]string{
+		"serving.version":                   "0.18.2",
+		"kafka.channel.enabled":             "true",
+		"kafka.source.enabled":              "false",
+		"revision.count":                    "1",
+		"revision.namespaces":               "1",
+		"broker.count":                      "1",
+		"broker.namespaces":                 "1",
+		"broker.class.Kafka":                "1",
+		"broker.class.MTChannelBasedBroker": "0",
+		"broker.class.other":                "0",
+	}
+	for key, value := range data {
+		if _, ok := want[key]; !ok && value != "0" {
+			t.Errorf("Summary()[%q] = %q, want 0", key, value)
+		}
+	}
+	got := map[string]string{}
+	for key := range want {
+		got[key] = data[key]
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Summary() (-want, +got) = %s", diff)
+	}
+	if _, ok := data["eventing.version"]; ok {
+		t.Error("Summary() reported the version of Eventing, which isn't installed")
+	}
+}
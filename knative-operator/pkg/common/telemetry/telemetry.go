@@ -1,87 +1,340 @@
 package telemetry
 
 import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
 var log = common.Log.WithName("telemetry")
 
+// The states of the telemetry of a component, as reported by the state metric.
+const (
+	stateStopped  = "stopped"
+	stateRunning  = "running"
+	stateFailed   = "failed"
+	stateDisabled = "disabled"
+)
+
+// The bounds of the interval between attempts to start the telemetry after a failure. Each
+// failed controller leaves the event handlers of the sources started so far registered with the
+// informers of the manager, so failures are not retried on every reconciliation.
+const (
+	minRetryInterval = 10 * time.Second
+	maxRetryInterval = 10 * time.Minute
+)
+
+// Telemetry counts the resources of a component while the component is installed. It can be
+// started and stopped any number of times, concurrently from several reconciliations.
+//
+// The first start creates a controller watching the resources, which keeps running while the
+// telemetry is stopped: a controller can't be restarted and the event handlers of its sources
+// can't be removed from the informers of the manager. The resources are only counted while the
+// telemetry is running. As the informers don't replay the resources to a running controller,
+// the counts are seeded from the cache when the telemetry is started again.
+//
+// A controller failing to start is replaced by a new one, with an exponential backoff.
 type Telemetry struct {
-	name string
-	stop chan struct{}
-	tc   controller.Controller
-	// Protects from processing order, if true we should install telemetry
-	// if it is false we need to uninstall in the next delete stage.
-	// We start by assuming no telemetry is available.
-	shouldInstallTelemetry bool
-	objects                []runtime.Object
+	name    string
+	objects []runtime.Object
+	// disabled is set if the cluster opted out of telemetry, see Disabled.
+	disabled bool
+	// scheme maps the objects to the lists seeding the counts.
+	scheme *runtime.Scheme
+	// newController creates a controller counting the events with the given predicate.
+	newController func(mgr manager.Manager, name string, p predicate.Predicate) (watcher, error)
+
+	mu sync.Mutex
+	// controller is the controller counting the resources, once created, and watched the kinds
+	// it watches. generation identifies the controller, as replaced ones keep receiving events.
+	controller watcher
+	watched    map[reflect.Type]bool
+	generation uint64
+	// active is the generation of the controller while the telemetry is running, or 0. It's read
+	// atomically by the predicates, along with counted, the types of the objects.
+	active  uint64
+	counted atomic.Value
+	// failures is the number of failed starts since the telemetry was last stopped, retryAt the
+	// earliest time of the next start after a failure.
+	failures int
+	retryAt  time.Time
+	now      func() time.Time
 }
 
 func NewTelemetry(name string, mgr manager.Manager, objects []runtime.Object, api client.Client) (*Telemetry, error) {
-	tc, err := newTelemetryController(name, objects, mgr)
-	if err != nil {
-		return nil, err
+	t := &Telemetry{
+		name:          name,
+		disabled:      Disabled(),
+		scheme:        scheme.Scheme,
+		newController: newTelemetryController,
+		now:           time.Now,
+	}
+	if mgr != nil {
+		t.scheme = mgr.GetScheme()
 	}
-	return &Telemetry{
-		name:                   name,
-		stop:                   make(chan struct{}),
-		objects:                objects,
-		shouldInstallTelemetry: true,
-		tc:                     tc,
-	}, nil
+	t.setObjects(objects)
+	if t.disabled {
+		log.Info("telemetry is disabled for:", "component", name)
+		t.setState(stateDisabled)
+		return t, nil
+	}
+	t.setState(stateStopped)
+	return t, nil
 }
 
 // TryStart setups telemetry per component either Eventing, KnativeKafka or Serving.
-// When called it assumes that the component has status ready.
+// When called it assumes that the component has status ready. It does nothing if the
+// telemetry is running already or disabled, or if a failed start is not to be retried yet.
 func (t *Telemetry) TryStart(api client.Client, mgr manager.Manager) error {
-	if t == nil {
+	if t == nil || t.disabled {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.running() || t.now().Before(t.retryAt) {
 		return nil
 	}
-	if t.shouldInstallTelemetry {
-		log.Info("starting telemetry for:", "component", t.name)
-		// Initialize metrics before we start the corresponding controller.
-		// There is a tiny window to miss events here, but should be ok for telemetry purposes.
-		t.initializeAndUpdateMetrics(api)
-		// Start our controller in a goroutine so that we do not block.
+
+	log.Info("starting telemetry for:", "component", t.name)
+	started := t.controller != nil
+	if !started {
+		generation := t.generation + 1
+		tc, err := t.newController(mgr, t.name, metricsPredicate{telemetry: t, generation: generation})
+		if err != nil {
+			t.backOff()
+			t.setState(stateFailed)
+			return err
+		}
+		t.controller = tc
+		t.watched = map[reflect.Type]bool{}
+		t.generation = generation
+	}
+
+	// Count from scratch. The kinds watched from now on are replayed by their informers, the
+	// resources of the kinds watched already are listed from the cache.
+	t.resetCounters()
+	atomic.StoreUint64(&t.active, t.generation)
+	var seeded []runtime.Object
+	for _, obj := range t.objects {
+		if t.watched[reflect.TypeOf(obj)] {
+			seeded = append(seeded, obj)
+			continue
+		}
+		watched, err := t.controller.watchKind(obj)
+		if err != nil {
+			return t.startFailed(err)
+		}
+		if watched {
+			t.watched[reflect.TypeOf(obj)] = true
+		}
+	}
+	if started {
+		if err := t.seedCounters(api, seeded); err != nil {
+			return t.startFailed(err)
+		}
+	} else {
+		stop := make(chan struct{})
+		tc, generation := t.controller, t.generation
+		// Start our controller in a goroutine so that we do not block. It runs as long as the
+		// operator, as it's only ever stopped by a failure to start.
 		go func() {
-			// Start our controller. This will block until it is stopped
-			// or the controller returns a starting error.
-			if err := t.tc.Start(t.stop); err != nil {
+			if err := tc.Start(stop); err != nil {
 				log.Error(err, "cannot start telemetry controller for", "component", t.name)
+				t.failed(generation)
 			}
 		}()
-		t.shouldInstallTelemetry = false
 	}
+	t.setState(stateRunning)
 	return nil
 }
 
 // TryStop stops telemetry per component either Eventing, KnativeKafka or Serving
-// When called it assumes that we are reconciling a deletion event.
+// When called it assumes that we are reconciling a deletion event. It does nothing if the
+// telemetry is not running.
 func (t *Telemetry) TryStop() {
 	if t == nil {
 		return
 	}
-	if !t.shouldInstallTelemetry {
-		log.Info("stopping telemetry for:", "component", t.name)
-		// Stop the telemetry controller
-		close(t.stop)
-		// Can't use a closed channel
-		t.stop = make(chan struct{})
-		t.shouldInstallTelemetry = true
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.running() {
+		return
 	}
+
+	log.Info("stopping telemetry for:", "component", t.name)
+	atomic.StoreUint64(&t.active, 0)
+	t.resetBackOff()
+	t.resetCounters()
+	t.setState(stateStopped)
 }
 
-// initializeAndUpdateMetrics is used for taking a global snapshot of metrics
-// before we start a telemetry controller. Cost should be low since we are fetching from cache.
-func (t *Telemetry) initializeAndUpdateMetrics(api client.Client) {
+// SetObjects sets the objects counted by the telemetry, e.g. as parts of the component are enabled
+// or disabled. If the telemetry is running, it's stopped, so that the next TryStart counts the given
+// objects.
+func (t *Telemetry) SetObjects(objects []runtime.Object) {
 	if t == nil {
 		return
 	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if sameTypes(t.objects, objects) {
+		return
+	}
+	if t.running() {
+		log.Info("restarting telemetry for changed objects:", "component", t.name)
+		atomic.StoreUint64(&t.active, 0)
+		t.resetCounters()
+		t.setState(stateStopped)
+	}
+	t.resetBackOff()
+	t.setObjects(objects)
+}
+
+// setObjects sets the objects counted by the telemetry. The caller must hold the lock, unless the
+// telemetry isn't shared yet.
+func (t *Telemetry) setObjects(objects []runtime.Object) {
+	types := make(map[reflect.Type]bool, len(objects))
+	for _, obj := range objects {
+		types[reflect.TypeOf(obj)] = true
+	}
+	t.objects = objects
+	t.counted.Store(types)
+}
+
+// running returns true if the telemetry counts the resources. The caller must hold the lock.
+func (t *Telemetry) running() bool {
+	return atomic.LoadUint64(&t.active) != 0
+}
+
+// startFailed stops counting after the running controller failed to watch or list the resources.
+// The controller is kept, as its watches can't be undone. The caller must hold the lock.
+func (t *Telemetry) startFailed(err error) error {
+	atomic.StoreUint64(&t.active, 0)
+	t.resetCounters()
+	t.backOff()
+	t.setState(stateFailed)
+	return err
+}
+
+// failed marks the telemetry as not running if the controller of the given generation failed,
+// so that a new one is started by a TryStart once the backoff passed.
+func (t *Telemetry) failed(generation uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.generation != generation || t.controller == nil {
+		// Replaced meanwhile.
+		return
+	}
+	t.controller = nil
+	t.watched = nil
+	if t.running() {
+		atomic.StoreUint64(&t.active, 0)
+		t.resetCounters()
+	}
+	t.backOff()
+	t.setState(stateFailed)
+}
+
+// seedCounters counts the resources of the kinds of the given objects from the cache.
+func (t *Telemetry) seedCounters(api client.Client, objects []runtime.Object) error {
+	for _, obj := range objects {
+		c := counterFor(obj)
+		if c == nil {
+			continue
+		}
+		gvk, err := apiutil.GVKForObject(obj, t.scheme)
+		if err != nil {
+			return err
+		}
+		list, err := t.scheme.New(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err != nil {
+			return err
+		}
+		if err := api.List(context.TODO(), list); err != nil {
+			return fmt.Errorf("failed to list %s: %w", gvk.Kind, err)
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			c.add(item)
+		}
+	}
+	return nil
+}
+
+// backOff delays the next start after a failure. The caller must hold the lock.
+func (t *Telemetry) backOff() {
+	interval := minRetryInterval
+	for i := 0; i < t.failures && interval < maxRetryInterval; i++ {
+		interval *= 2
+	}
+	if interval > maxRetryInterval {
+		interval = maxRetryInterval
+	}
+	t.failures++
+	t.retryAt = t.now().Add(interval)
+	log.Info("telemetry failed to start, retrying later:", "component", t.name, "after", interval)
+}
+
+// resetBackOff allows the next start right away. The caller must hold the lock.
+func (t *Telemetry) resetBackOff() {
+	t.failures = 0
+	t.retryAt = time.Time{}
+}
+
+// sameTypes returns true if the given objects are of the same types.
+func sameTypes(a, b []runtime.Object) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if reflect.TypeOf(a[i]) != reflect.TypeOf(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// counting returns true if the controller of the given generation counts the given object: the
+// telemetry is running with that controller and counts the kind of the object.
+func (t *Telemetry) counting(generation uint64, obj runtime.Object) bool {
+	if atomic.LoadUint64(&t.active) != generation {
+		return false
+	}
+	types, _ := t.counted.Load().(map[reflect.Type]bool)
+	return types[reflect.TypeOf(obj)]
+}
+
+// resetCounters forgets the resources counted for the component.
+func (t *Telemetry) resetCounters() {
 	for _, obj := range t.objects {
-		updateMetricFor(obj, api)
+		if c := counterFor(obj); c != nil {
+			c.reset()
+		}
+	}
+}
+
+// setState reports the given state of the telemetry.
+func (t *Telemetry) setState(state string) {
+	for _, s := range []string{stateStopped, stateRunning, stateFailed, stateDisabled} {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		telemetryStateG.WithLabelValues(t.name, s).Set(value)
 	}
 }
@@ -0,0 +1,178 @@
+package telemetry
+
+import (
+	"reflect"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kafkachannelv1beta1 "knative.dev/eventing-contrib/kafka/channel/pkg/apis/messaging/v1beta1"
+	kafkasourcev1beta1 "knative.dev/eventing-contrib/kafka/source/pkg/apis/sources/v1beta1"
+	"knative.dev/eventing/pkg/apis/eventing"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	messagingv1 "knative.dev/eventing/pkg/apis/messaging/v1"
+	eventingsourcesv1beta1 "knative.dev/eventing/pkg/apis/sources/v1beta1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+// brokerClasses are the broker classes reported by name. Brokers of any other class are
+// reported as "other", as the class is an arbitrary annotation.
+var brokerClasses = []string{eventing.MTChannelBrokerClassValue, "Kafka"}
+
+// counters count the resources of each type watched for telemetry.
+var counters = map[reflect.Type]*counter{
+	reflect.TypeOf(&servingv1.Service{}):                      newCounter("service"),
+	reflect.TypeOf(&servingv1.Revision{}):                     newCounter("revision"),
+	reflect.TypeOf(&servingv1.Route{}):                        newCounter("route"),
+	reflect.TypeOf(&servingv1.Configuration{}):                newCounter("configuration"),
+	reflect.TypeOf(&eventingsourcesv1beta1.PingSource{}):      newCounter("source_ping"),
+	reflect.TypeOf(&eventingsourcesv1beta1.ApiServerSource{}): newCounter("source_apiserver"),
+	reflect.TypeOf(&eventingsourcesv1beta1.SinkBinding{}):     newCounter("source_sinkbinding"),
+	reflect.TypeOf(&eventingsourcesv1beta1.ContainerSource{}): newCounter("source_container"),
+	reflect.TypeOf(&kafkasourcev1beta1.KafkaSource{}):         newCounter("source_kafka"),
+	reflect.TypeOf(&eventingv1.Broker{}):                      newBrokerCounter(),
+	reflect.TypeOf(&eventingv1.Trigger{}):                     newCounter("trigger"),
+	reflect.TypeOf(&messagingv1.InMemoryChannel{}):            newCounter("channel_inmemory"),
+	reflect.TypeOf(&messagingv1.Subscription{}):               newCounter("subscription"),
+	reflect.TypeOf(&kafkachannelv1beta1.KafkaChannel{}):       newCounter("channel_kafka"),
+}
+
+// counterFor returns the counter of the type of the given object, or nil if it isn't counted.
+func counterFor(obj runtime.Object) *counter {
+	return counters[reflect.TypeOf(obj)]
+}
+
+// counter counts the resources of a type, the namespaces using them and optionally their
+// classes from the events of their informer. The resources are tracked by key, so events
+// replayed when an informer starts or resyncs don't skew the counts and each event is
+// processed in constant time.
+type counter struct {
+	typ string
+	// class returns the class of a resource, if the resources are counted by class.
+	class func(metav1.Object) string
+
+	mu         sync.Mutex
+	resources  map[types.NamespacedName]string
+	namespaces map[string]int
+	classes    map[string]int
+}
+
+func newCounter(typ string) *counter {
+	c := &counter{typ: typ}
+	c.clear()
+	return c
+}
+
+func newBrokerCounter() *counter {
+	c := &counter{typ: "broker", class: brokerClass}
+	c.clear()
+	return c
+}
+
+// brokerClass returns the class of a broker, or "other" if it's not a known class.
+func brokerClass(obj metav1.Object) string {
+	class := obj.GetAnnotations()[eventing.BrokerClassKey]
+	for _, known := range brokerClasses {
+		if class == known {
+			return class
+		}
+	}
+	return "other"
+}
+
+// add counts the given resource, if it isn't counted yet, or updates its class.
+func (c *counter) add(obj runtime.Object) {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	class := ""
+	if c.class != nil {
+		class = c.class(m)
+	}
+	key := types.NamespacedName{Namespace: m.GetNamespace(), Name: m.GetName()}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if previous, ok := c.resources[key]; ok {
+		if previous == class {
+			return
+		}
+		c.classes[previous]--
+	} else {
+		c.namespaces[key.Namespace]++
+	}
+	c.resources[key] = class
+	c.classes[class]++
+	c.update()
+}
+
+// remove stops counting the given resource.
+func (c *counter) remove(obj runtime.Object) {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	key := types.NamespacedName{Namespace: m.GetNamespace(), Name: m.GetName()}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	class, ok := c.resources[key]
+	if !ok {
+		return
+	}
+	delete(c.resources, key)
+	c.classes[class]--
+	if c.namespaces[key.Namespace]--; c.namespaces[key.Namespace] == 0 {
+		delete(c.namespaces, key.Namespace)
+	}
+	c.update()
+}
+
+// reset forgets all resources, e.g. before their informer replays them, and reports the counts.
+// The series are only created by reset, add and remove, i.e. once the telemetry started.
+func (c *counter) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clear()
+	c.update()
+}
+
+// clear forgets all resources. The caller must hold the lock, unless the counter isn't shared yet.
+func (c *counter) clear() {
+	c.resources = map[types.NamespacedName]string{}
+	c.namespaces = map[string]int{}
+	c.classes = map[string]int{}
+	if c.class != nil {
+		for _, class := range brokerClasses {
+			c.classes[class] = 0
+		}
+		c.classes["other"] = 0
+	}
+}
+
+// counts returns the number of resources, the number of namespaces using them and the number
+// of resources per class.
+func (c *counter) counts() (int, int, map[string]int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	classes := make(map[string]int, len(c.classes))
+	for class, count := range c.classes {
+		classes[class] = count
+	}
+	return len(c.resources), len(c.namespaces), classes
+}
+
+// update sets the gauges to the current counts. The caller must hold the lock.
+func (c *counter) update() {
+	serverlessTelemetryG.WithLabelValues(c.typ).Set(float64(len(c.resources)))
+	serverlessNamespaceG.WithLabelValues(c.typ).Set(float64(len(c.namespaces)))
+	if c.class == nil {
+		return
+	}
+	for class, count := range c.classes {
+		brokerClassG.WithLabelValues(class).Set(float64(count))
+	}
+}
@@ -13,17 +13,33 @@ var (
 		},
 		[]string{"type"},
 	)
-	serviceG           = serverlessTelemetryG.WithLabelValues("service")
-	routeG             = serverlessTelemetryG.WithLabelValues("route")
-	revisionG          = serverlessTelemetryG.WithLabelValues("revision")
-	configurationG     = serverlessTelemetryG.WithLabelValues("configuration")
-	pingSourceG        = serverlessTelemetryG.WithLabelValues("source_ping")
-	apiServerSourceG   = serverlessTelemetryG.WithLabelValues("source_apiserver")
-	sinkBindingSourceG = serverlessTelemetryG.WithLabelValues("source_sinkbinding")
-	kafkaSourceG       = serverlessTelemetryG.WithLabelValues("source_kafka")
+
+	serverlessNamespaceG = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "serverless_telemetry_namespaces",
+			Help: "Reports number of namespaces using a type of serverless resources for telemetry",
+		},
+		[]string{"type"},
+	)
+
+	brokerClassG = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "serverless_telemetry_broker_class",
+			Help: "Reports number of brokers per broker class for telemetry",
+		},
+		[]string{"class"},
+	)
+)
+
+var telemetryStateG = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "serverless_telemetry_state",
+		Help: "Reports the state of the telemetry of a component, 1 for the current state",
+	},
+	[]string{"component", "state"},
 )
 
 func init() {
 	// Register custom telemetry metrics with the global prometheus registry
-	metrics.Registry.MustRegister(serverlessTelemetryG)
+	metrics.Registry.MustRegister(serverlessTelemetryG, serverlessNamespaceG, brokerClassG, telemetryStateG)
 }
@@ -0,0 +1,348 @@
+package telemetry
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// fakeControllers records the controllers created for a telemetry instead of watching resources.
+type fakeControllers struct {
+	// startErr is returned by Start instead of blocking until the test is done.
+	startErr error
+
+	mu         sync.Mutex
+	predicates []predicate.Predicate
+	watches    [][]runtime.Object
+	running    sync.WaitGroup
+	done       chan struct{}
+}
+
+func newFakeControllers() *fakeControllers {
+	return &fakeControllers{done: make(chan struct{})}
+}
+
+func (f *fakeControllers) newController(_ manager.Manager, _ string, p predicate.Predicate) (watcher, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.predicates = append(f.predicates, p)
+	f.watches = append(f.watches, nil)
+	f.running.Add(1)
+	return &fakeController{fakeControllers: f, index: len(f.predicates) - 1}, nil
+}
+
+func (f *fakeControllers) created() []predicate.Predicate {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]predicate.Predicate(nil), f.predicates...)
+}
+
+func (f *fakeControllers) watched(index int) []runtime.Object {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]runtime.Object(nil), f.watches[index]...)
+}
+
+// stop stops the controllers that are still running and waits for them.
+func (f *fakeControllers) stop() {
+	close(f.done)
+	f.running.Wait()
+}
+
+type fakeController struct {
+	*fakeControllers
+	index int
+}
+
+func (c *fakeController) watchKind(obj runtime.Object) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.watches[c.index] = append(c.watches[c.index], obj)
+	return true, nil
+}
+
+func (c *fakeController) Start(stop <-chan struct{}) error {
+	defer c.running.Done()
+	if c.startErr != nil {
+		return c.startErr
+	}
+	select {
+	case <-stop:
+	case <-c.done:
+	}
+	return nil
+}
+
+// fakeClock is the clock of the backoff of failed starts.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Step(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newFakeTelemetry(name string, controllers *fakeControllers) *Telemetry {
+	tm, _ := NewTelemetry(name, nil, []runtime.Object{revision}, nil)
+	tm.newController = controllers.newController
+	return tm
+}
+
+func assertState(t *testing.T, name, want string) {
+	t.Helper()
+	for _, state := range []string{stateStopped, stateRunning, stateFailed, stateDisabled} {
+		expected := 0.0
+		if state == want {
+			expected = 1
+		}
+		if got := value(t, telemetryStateG.WithLabelValues(name, state)); got != expected {
+			t.Errorf("State %s of %s = %v, want %v", state, name, got, expected)
+		}
+	}
+}
+
+func TestStartStopCycles(t *testing.T) {
+	controllers := newFakeControllers()
+	defer controllers.stop()
+	tm := newFakeTelemetry("cycles", controllers)
+	assertState(t, "cycles", stateStopped)
+	other := revision.DeepCopy()
+	other.Name = "other"
+	api := fake.NewFakeClient(other)
+
+	for cycle := 1; cycle <= 3; cycle++ {
+		for i := 0; i < 2; i++ {
+			if err := tm.TryStart(api, nil); err != nil {
+				t.Fatalf("TryStart() = %v", err)
+			}
+		}
+		predicates := controllers.created()
+		if len(predicates) != 1 {
+			t.Fatalf("Got %d controllers after %d starts, want a single one", len(predicates), cycle)
+		}
+		if got := controllers.watched(0); len(got) != 1 || got[0] != revision {
+			t.Errorf("Watched objects = %v, want the revision watched once", got)
+		}
+		assertState(t, "cycles", stateRunning)
+
+		// The informer replays the resources when the controller starts. Restarts count them
+		// from the cache instead.
+		if cycle == 1 {
+			predicates[0].Create(event.CreateEvent{Meta: other, Object: other})
+		}
+		predicates[0].Create(event.CreateEvent{Meta: revision, Object: revision})
+		if got := value(t, serverlessTelemetryG.WithLabelValues("revision")); got != 2 {
+			t.Errorf("Revisions counted in cycle %d = %v, want 2", cycle, got)
+		}
+
+		tm.TryStop()
+		tm.TryStop()
+		assertState(t, "cycles", stateStopped)
+		if got := value(t, serverlessTelemetryG.WithLabelValues("revision")); got != 0 {
+			t.Errorf("Revisions counted after stop = %v, want 0", got)
+		}
+		// Events are not counted while stopped.
+		predicates[0].Create(event.CreateEvent{Meta: revision, Object: revision})
+		if got := value(t, serverlessTelemetryG.WithLabelValues("revision")); got != 0 {
+			t.Errorf("Revisions counted while stopped = %v, want 0", got)
+		}
+	}
+}
+
+func TestStartFailure(t *testing.T) {
+	controllers := newFakeControllers()
+	controllers.startErr = errors.New("cannot sync")
+	defer controllers.stop()
+	tm := newFakeTelemetry("failure", controllers)
+	clock := &fakeClock{now: time.Now()}
+	tm.now = clock.Now
+
+	fail := func() {
+		t.Helper()
+		if err := tm.TryStart(nil, nil); err != nil {
+			t.Fatalf("TryStart() = %v", err)
+		}
+		controllers.running.Wait()
+		if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+			return value(t, telemetryStateG.WithLabelValues("failure", stateFailed)) == 1, nil
+		}); err != nil {
+			t.Fatal("Telemetry not reported as failed")
+		}
+		assertState(t, "failure", stateFailed)
+	}
+	fail()
+
+	// The failure is not retried right away, as each attempt leaks the handlers of its sources.
+	if err := tm.TryStart(nil, nil); err != nil {
+		t.Fatalf("TryStart() = %v", err)
+	}
+	if got := len(controllers.created()); got != 1 {
+		t.Errorf("Got %d controllers, want no new one right after the failure", got)
+	}
+
+	// The next start after the backoff retries, and backs off longer if it fails again.
+	clock.Step(minRetryInterval)
+	fail()
+	if got := len(controllers.created()); got != 2 {
+		t.Errorf("Got %d controllers, want a new one after the backoff", got)
+	}
+	clock.Step(minRetryInterval)
+	if err := tm.TryStart(nil, nil); err != nil {
+		t.Fatalf("TryStart() = %v", err)
+	}
+	if got := len(controllers.created()); got != 2 {
+		t.Errorf("Got %d controllers, want no new one before the doubled backoff passed", got)
+	}
+
+	clock.Step(minRetryInterval)
+	controllers.startErr = nil
+	if err := tm.TryStart(nil, nil); err != nil {
+		t.Fatalf("TryStart() = %v", err)
+	}
+	if got := len(controllers.created()); got != 3 {
+		t.Errorf("Got %d controllers, want a new one after the doubled backoff", got)
+	}
+	assertState(t, "failure", stateRunning)
+	// The events of the failed controllers are not counted.
+	for i, p := range controllers.created() {
+		if counting := p.(metricsPredicate).counting(revision); counting != (i == 2) {
+			t.Errorf("Controller %d counting = %v, want %v", i, counting, i == 2)
+		}
+	}
+	tm.TryStop()
+}
+
+func TestSetObjects(t *testing.T) {
+	controllers := newFakeControllers()
+	defer controllers.stop()
+	tm := newFakeTelemetry("objects", controllers)
+	api := fake.NewFakeClient()
+
+	if err := tm.TryStart(api, nil); err != nil {
+		t.Fatalf("TryStart() = %v", err)
+	}
+	// The same objects keep the telemetry running.
+	tm.SetObjects([]runtime.Object{revision})
+	if err := tm.TryStart(api, nil); err != nil {
+		t.Fatalf("TryStart() = %v", err)
+	}
+	assertState(t, "objects", stateRunning)
+
+	// Other objects restart the telemetry, watching the new objects with the same controller.
+	service := &servingv1.Service{}
+	tm.SetObjects([]runtime.Object{revision, service})
+	assertState(t, "objects", stateStopped)
+	if err := tm.TryStart(api, nil); err != nil {
+		t.Fatalf("TryStart() = %v", err)
+	}
+	if got := len(controllers.created()); got != 1 {
+		t.Fatalf("Got %d controllers, want a single one", got)
+	}
+	if got := controllers.watched(0); len(got) != 2 || got[0] != revision || got[1] != service {
+		t.Errorf("Watched objects = %v, want the revision and the service", got)
+	}
+	assertState(t, "objects", stateRunning)
+
+	// The objects no longer counted are ignored, although still watched.
+	tm.SetObjects([]runtime.Object{revision})
+	if err := tm.TryStart(api, nil); err != nil {
+		t.Fatalf("TryStart() = %v", err)
+	}
+	p := controllers.created()[0].(metricsPredicate)
+	if !p.counting(revision) || p.counting(service) {
+		t.Errorf("Counting revisions, services = %v, %v, want true, false", p.counting(revision), p.counting(service))
+	}
+	if got := controllers.watched(0); len(got) != 2 {
+		t.Errorf("Watched objects = %v, want no new watch", got)
+	}
+	tm.TryStop()
+}
+
+func TestConcurrentStartStop(t *testing.T) {
+	controllers := newFakeControllers()
+	defer controllers.stop()
+	tm := newFakeTelemetry("concurrent", controllers)
+	api := fake.NewFakeClient()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := tm.TryStart(api, nil); err != nil {
+				t.Errorf("TryStart() = %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			tm.TryStop()
+		}()
+	}
+	wg.Wait()
+
+	if got := len(controllers.created()); got != 1 {
+		t.Errorf("Got %d controllers, want a single one", got)
+	}
+	tm.TryStop()
+	if controllers.created()[0].(metricsPredicate).counting(revision) {
+		t.Error("Counting after the telemetry stopped")
+	}
+	assertState(t, "concurrent", stateStopped)
+}
+
+func TestDisabled(t *testing.T) {
+	os.Setenv(DisabledEnvKey, "true")
+	defer os.Unsetenv(DisabledEnvKey)
+	// Forget the series of the other tests, and create counters as the package does.
+	serverlessTelemetryG.Reset()
+	serverlessNamespaceG.Reset()
+	brokerClassG.Reset()
+	newCounter("disabled")
+	newBrokerCounter()
+
+	controllers := newFakeControllers()
+	defer controllers.stop()
+	tm := newFakeTelemetry("disabled", controllers)
+	assertState(t, "disabled", stateDisabled)
+
+	tm.SetObjects([]runtime.Object{revision, broker})
+	if err := tm.TryStart(nil, nil); err != nil {
+		t.Fatalf("TryStart() = %v", err)
+	}
+	tm.TryStop()
+	if got := len(controllers.created()); got != 0 {
+		t.Errorf("Got %d controllers, want none if telemetry is disabled", got)
+	}
+	assertState(t, "disabled", stateDisabled)
+
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		switch family.GetName() {
+		case "serverless_telemetry", "serverless_telemetry_namespaces", "serverless_telemetry_broker_class":
+			t.Errorf("Got %d series of %s, want none if telemetry is disabled", len(family.GetMetric()), family.GetName())
+		}
+	}
+}
@@ -1,14 +1,11 @@
 package telemetry
 
 import (
-	"context"
 	"fmt"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
-	kafkasourcev1beta1 "knative.dev/eventing-contrib/kafka/source/pkg/apis/sources/v1beta1"
-	eventingsourcesv1beta1 "knative.dev/eventing/pkg/apis/sources/v1beta1"
-	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
-	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -18,8 +15,25 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
+// watcher is the controller counting the resources of a telemetry from the events of their informers.
+type watcher interface {
+	// watchKind watches the kind of the given object. It returns false if the kind isn't served
+	// by the cluster. Kinds watched after the controller started are watched right away.
+	watchKind(obj runtime.Object) (bool, error)
+	// Start starts the watches and blocks until the controller is stopped or fails to start.
+	Start(stop <-chan struct{}) error
+}
+
+// telemetryController watches the kinds of a telemetry, counting their resources with its predicate.
+type telemetryController struct {
+	controller.Controller
+	mapper    meta.RESTMapper
+	scheme    *runtime.Scheme
+	predicate predicate.Predicate
+}
+
 // newTelemetryController creates an unmanaged controller for watching Telemetry resources
-func newTelemetryController(name string, objects []runtime.Object, mgr manager.Manager) (controller.Controller, error) {
+func newTelemetryController(mgr manager.Manager, name string, p predicate.Predicate) (watcher, error) {
 	// Create a new controller
 	c, err := controller.NewUnmanaged(fmt.Sprintf("telemetry-resources-%s-controller", name), mgr, controller.Options{
 		Reconciler: reconcile.Func(func(reconcile.Request) (reconcile.Result, error) { // No actual update happens here
@@ -29,76 +43,77 @@ func newTelemetryController(name string, objects []runtime.Object, mgr manager.M
 	if err != nil {
 		return nil, err
 	}
-	for _, tp := range objects {
-		if err := c.Watch(&source.Kind{Type: tp}, &handler.EnqueueRequestForObject{}, metricsPredicate{
-			api: mgr.GetClient(),
-		}); err != nil {
+	return &telemetryController{Controller: c, mapper: mgr.GetRESTMapper(), scheme: mgr.GetScheme(), predicate: p}, nil
+}
+
+func (c *telemetryController) watchKind(obj runtime.Object) (bool, error) {
+	served, err := servedObjects(c.mapper, c.scheme, []runtime.Object{obj})
+	if err != nil || len(served) == 0 {
+		return false, err
+	}
+	return true, c.Watch(&source.Kind{Type: obj}, &handler.EnqueueRequestForObject{}, c.predicate)
+}
+
+// servedObjects returns the objects of the kinds served by the cluster. The sources of kinds not
+// served would fail to start, e.g. if an optional part of a component isn't installed.
+func servedObjects(mapper meta.RESTMapper, scheme *runtime.Scheme, objects []runtime.Object) ([]runtime.Object, error) {
+	var served []runtime.Object
+	for _, obj := range objects {
+		gvk, err := apiutil.GVKForObject(obj, scheme)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version); meta.IsNoMatchError(err) {
+			log.Info("Not counting resources not served by the cluster", "kind", gvk.Kind)
+			continue
+		} else if err != nil {
 			return nil, err
 		}
+		served = append(served, obj)
 	}
-	return c, nil
+	return served, nil
 }
 
+// metricsPredicate counts the resources from their events. No events are passed on, as the
+// counts are all there is to telemetry. Only the events of the controller of the telemetry
+// are counted, if any is set, while the telemetry is running and counts their kind.
 type metricsPredicate struct {
 	predicate.Funcs
-	api client.Client
+	telemetry  *Telemetry
+	generation uint64
 }
 
-func (metricsPredicate) Update(_ event.UpdateEvent) bool {
-	return false
+func (p metricsPredicate) counting(obj runtime.Object) bool {
+	return p.telemetry == nil || p.telemetry.counting(p.generation, obj)
 }
 
-func (mp metricsPredicate) Create(e event.CreateEvent) bool {
-	updateMetricFor(e.Object, mp.api)
+func (p metricsPredicate) Create(e event.CreateEvent) bool {
+	if !p.counting(e.Object) {
+		return false
+	}
+	if c := counterFor(e.Object); c != nil {
+		c.add(e.Object)
+	}
 	return false
 }
 
-func (mp metricsPredicate) Delete(e event.DeleteEvent) bool {
-	updateMetricFor(e.Object, mp.api)
+// Update counts the resource as well, to recover from missed events on resyncs.
+func (p metricsPredicate) Update(e event.UpdateEvent) bool {
+	if !p.counting(e.ObjectNew) {
+		return false
+	}
+	if c := counterFor(e.ObjectNew); c != nil {
+		c.add(e.ObjectNew)
+	}
 	return false
 }
 
-func updateMetricFor(obj runtime.Object, api client.Client) {
-	switch obj.(type) {
-	case *servingv1.Service:
-		serviceList := &servingv1.ServiceList{}
-		if err := api.List(context.TODO(), serviceList); err == nil {
-			serviceG.Set(float64(len(serviceList.Items)))
-		}
-	case *servingv1.Revision:
-		revisionList := &servingv1.RevisionList{}
-		if err := api.List(context.TODO(), revisionList); err == nil {
-			revisionG.Set(float64(len(revisionList.Items)))
-		}
-	case *servingv1.Route:
-		routeList := &servingv1.RouteList{}
-		if err := api.List(context.TODO(), routeList); err == nil {
-			routeG.Set(float64(len(routeList.Items)))
-		}
-	case *servingv1.Configuration:
-		configurationList := &servingv1.ConfigurationList{}
-		if err := api.List(context.TODO(), configurationList); err == nil {
-			configurationG.Set(float64(len(configurationList.Items)))
-		}
-	case *eventingsourcesv1beta1.PingSource:
-		pingSourceList := &eventingsourcesv1beta1.PingSourceList{}
-		if err := api.List(context.TODO(), pingSourceList); err == nil {
-			pingSourceG.Set(float64(len(pingSourceList.Items)))
-		}
-	case *eventingsourcesv1beta1.ApiServerSource:
-		apiServerSourceList := &eventingsourcesv1beta1.ApiServerSourceList{}
-		if err := api.List(context.TODO(), apiServerSourceList); err == nil {
-			apiServerSourceG.Set(float64(len(apiServerSourceList.Items)))
-		}
-	case *eventingsourcesv1beta1.SinkBinding:
-		sinkBindingList := &eventingsourcesv1beta1.SinkBindingList{}
-		if err := api.List(context.TODO(), sinkBindingList); err == nil {
-			sinkBindingSourceG.Set(float64(len(sinkBindingList.Items)))
-		}
-	case *kafkasourcev1beta1.KafkaSource:
-		knativeKafkaList := &kafkasourcev1beta1.KafkaSourceList{}
-		if err := api.List(context.TODO(), knativeKafkaList); err == nil {
-			kafkaSourceG.Set(float64(len(knativeKafkaList.Items)))
-		}
+func (p metricsPredicate) Delete(e event.DeleteEvent) bool {
+	if !p.counting(e.Object) {
+		return false
+	}
+	if c := counterFor(e.Object); c != nil {
+		c.remove(e.Object)
 	}
+	return false
 }
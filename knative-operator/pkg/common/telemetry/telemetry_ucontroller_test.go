@@ -8,10 +8,15 @@ import (
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
 	"github.com/prometheus/client_golang/prometheus"
 	ioprometheusclient "github.com/prometheus/client_model/go"
+	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
+	kafkachannelv1beta1 "knative.dev/eventing-contrib/kafka/channel/pkg/apis/messaging/v1beta1"
 	kafkasourcev1beta1 "knative.dev/eventing-contrib/kafka/source/pkg/apis/sources/v1beta1"
+	"knative.dev/eventing/pkg/apis/eventing"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	messagingv1 "knative.dev/eventing/pkg/apis/messaging/v1"
 	eventingsourcesv1beta1 "knative.dev/eventing/pkg/apis/sources/v1beta1"
 	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -51,6 +56,30 @@ var (
 		ObjectMeta: v1.ObjectMeta{
 			Name: "kafkasource",
 		}}
+	containerSource = &eventingsourcesv1beta1.ContainerSource{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "containersource",
+		}}
+	broker = &eventingv1.Broker{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "broker",
+		}}
+	trigger = &eventingv1.Trigger{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "trigger",
+		}}
+	inMemoryChannel = &messagingv1.InMemoryChannel{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "inmemorychannel",
+		}}
+	subscription = &messagingv1.Subscription{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "subscription",
+		}}
+	kafkaChannel = &kafkachannelv1beta1.KafkaChannel{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "kafkachannel",
+		}}
 )
 
 type metricCase struct {
@@ -67,8 +96,11 @@ func TestTelemetryMetricsUpdates(t *testing.T) {
 	// run this in a serialized manner so that values are predictable for sources
 	metricSteps := generateMetricUpdateSteps()
 	cl := fake.NewFakeClient()
+	for _, c := range counters {
+		c.reset()
+	}
 	for _, tc := range metricSteps {
-		mp := metricsPredicate{api: cl}
+		mp := metricsPredicate{}
 		dto := ioprometheusclient.Metric{}
 		var metric prometheus.Gauge
 		if create, ok := tc.event.(event.CreateEvent); ok {
@@ -134,6 +166,24 @@ func generateMetricUpdateSteps() (ret []metricCase) {
 	}, {
 		name: "kafkasource",
 		obj:  kafkaSource,
+	}, {
+		name: "containersource",
+		obj:  containerSource,
+	}, {
+		name: "broker",
+		obj:  broker,
+	}, {
+		name: "trigger",
+		obj:  trigger,
+	}, {
+		name: "inmemorychannel",
+		obj:  inMemoryChannel,
+	}, {
+		name: "subscription",
+		obj:  subscription,
+	}, {
+		name: "kafkachannel",
+		obj:  kafkaChannel,
 	}}
 	ret = []metricCase{}
 	for _, v := range objects {
@@ -145,7 +195,7 @@ func generateMetricUpdateSteps() (ret []metricCase) {
 			expectedMetricValue: 1,
 		}, metricCase{
 			name:                fmt.Sprintf("update a %s", v.name),
-			event:               event.UpdateEvent{ObjectOld: v.obj},
+			event:               event.UpdateEvent{ObjectOld: v.obj, ObjectNew: v.obj},
 			expectedMetricValue: 1,
 		}, metricCase{
 			name: fmt.Sprintf("delete a %s", v.name),
@@ -161,21 +211,150 @@ func generateMetricUpdateSteps() (ret []metricCase) {
 func getMetricFor(obj runtime.Object) prometheus.Gauge {
 	switch obj.(type) {
 	case *servingv1.Service:
-		return serviceG
+		return serverlessTelemetryG.WithLabelValues("service")
 	case *servingv1.Revision:
-		return revisionG
+		return serverlessTelemetryG.WithLabelValues("revision")
 	case *servingv1.Route:
-		return routeG
+		return serverlessTelemetryG.WithLabelValues("route")
 	case *servingv1.Configuration:
-		return configurationG
+		return serverlessTelemetryG.WithLabelValues("configuration")
 	case *eventingsourcesv1beta1.PingSource:
-		return pingSourceG
+		return serverlessTelemetryG.WithLabelValues("source_ping")
 	case *eventingsourcesv1beta1.ApiServerSource:
-		return apiServerSourceG
+		return serverlessTelemetryG.WithLabelValues("source_apiserver")
 	case *eventingsourcesv1beta1.SinkBinding:
-		return sinkBindingSourceG
+		return serverlessTelemetryG.WithLabelValues("source_sinkbinding")
+	case *eventingsourcesv1beta1.ContainerSource:
+		return serverlessTelemetryG.WithLabelValues("source_container")
 	case *kafkasourcev1beta1.KafkaSource:
-		return kafkaSourceG
+		return serverlessTelemetryG.WithLabelValues("source_kafka")
+	case *eventingv1.Broker:
+		return serverlessTelemetryG.WithLabelValues("broker")
+	case *eventingv1.Trigger:
+		return serverlessTelemetryG.WithLabelValues("trigger")
+	case *messagingv1.InMemoryChannel:
+		return serverlessTelemetryG.WithLabelValues("channel_inmemory")
+	case *messagingv1.Subscription:
+		return serverlessTelemetryG.WithLabelValues("subscription")
+	case *kafkachannelv1beta1.KafkaChannel:
+		return serverlessTelemetryG.WithLabelValues("channel_kafka")
 	}
 	return nil
 }
+
+func TestNamespacesAndBrokerClasses(t *testing.T) {
+	brokerIn := func(namespace, name, class string) *eventingv1.Broker {
+		return &eventingv1.Broker{ObjectMeta: v1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        name,
+			Annotations: map[string]string{eventing.BrokerClassKey: class},
+		}}
+	}
+	counterFor(broker).reset()
+	mp := metricsPredicate{}
+	for _, b := range []*eventingv1.Broker{
+		brokerIn("ns1", "default", eventing.MTChannelBrokerClassValue),
+		brokerIn("ns1", "kafka", "Kafka"),
+		brokerIn("ns2", "default", eventing.MTChannelBrokerClassValue),
+		brokerIn("ns3", "custom", "MyOwnBroker"),
+		brokerIn("ns4", "deleted", "Kafka"),
+	} {
+		mp.Create(event.CreateEvent{Object: b})
+		// Events are replayed when the informer resyncs.
+		mp.Update(event.UpdateEvent{ObjectOld: b, ObjectNew: b})
+	}
+	mp.Delete(event.DeleteEvent{Object: brokerIn("ns4", "deleted", "Kafka")})
+
+	for _, c := range []struct {
+		name  string
+		gauge prometheus.Gauge
+		want  float64
+	}{
+		{"brokers", serverlessTelemetryG.WithLabelValues("broker"), 4},
+		{"namespaces with brokers", serverlessNamespaceG.WithLabelValues("broker"), 3},
+		{"MTChannelBasedBroker class", brokerClassG.WithLabelValues(eventing.MTChannelBrokerClassValue), 2},
+		{"Kafka class", brokerClassG.WithLabelValues("Kafka"), 1},
+		{"other classes", brokerClassG.WithLabelValues("other"), 1},
+	} {
+		if got := value(t, c.gauge); got != c.want {
+			t.Errorf("%s = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func value(t testing.TB, gauge prometheus.Gauge) float64 {
+	t.Helper()
+	m := ioprometheusclient.Metric{}
+	if err := gauge.Write(&m); err != nil {
+		t.Fatal("Cannot write metric:", err)
+	}
+	return m.GetGauge().GetValue()
+}
+
+// BenchmarkCountEvents compares counting the revisions on each create event by listing all of
+// them, as done before, to counting them incrementally.
+func BenchmarkCountEvents(b *testing.B) {
+	for _, existing := range []int{100, 1000, 5000} {
+		revisions := make([]runtime.Object, existing)
+		for i := range revisions {
+			revisions[i] = &servingv1.Revision{ObjectMeta: v1.ObjectMeta{
+				Namespace: fmt.Sprintf("ns-%d", i%50),
+				Name:      fmt.Sprintf("revision-%d", i),
+			}}
+		}
+		revision := &servingv1.Revision{ObjectMeta: v1.ObjectMeta{Namespace: "ns-0", Name: "new"}}
+
+		b.Run(fmt.Sprintf("list/revisions=%d", existing), func(b *testing.B) {
+			cl := fake.NewFakeClient(revisions...)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				list := &servingv1.RevisionList{}
+				if err := cl.List(context.TODO(), list); err != nil {
+					b.Fatal(err)
+				}
+				serverlessTelemetryG.WithLabelValues("revision").Set(float64(len(list.Items)))
+			}
+		})
+
+		b.Run(fmt.Sprintf("incremental/revisions=%d", existing), func(b *testing.B) {
+			c := counterFor(revision)
+			c.reset()
+			for _, r := range revisions {
+				c.add(r)
+			}
+			mp := metricsPredicate{}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				mp.Create(event.CreateEvent{Object: revision})
+				mp.Delete(event.DeleteEvent{Object: revision})
+			}
+		})
+	}
+}
+
+func TestServedObjects(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := servingv1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := kafkachannelv1beta1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	// Only the sources of KafkaChannels would fail to start, as they're not served.
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(servingv1.SchemeGroupVersion.WithKind("Service"), meta.RESTScopeNamespace)
+	mapper.Add(servingv1.SchemeGroupVersion.WithKind("Revision"), meta.RESTScopeNamespace)
+
+	served, err := servedObjects(mapper, s, []runtime.Object{service, kafkaChannel, revision})
+	if err != nil {
+		t.Fatalf("servedObjects() = %v", err)
+	}
+	if len(served) != 2 || served[0] != service || served[1] != revision {
+		t.Errorf("servedObjects() = %v, want the service and the revision", served)
+	}
+
+	// Objects missing from the scheme are an error rather than skipped.
+	if _, err := servedObjects(mapper, s, []runtime.Object{broker}); err == nil {
+		t.Error("servedObjects() = nil error, want an error for an object not in the scheme")
+	}
+}
@@ -0,0 +1,136 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opencensus.io/trace"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// Client wraps the given client to record its calls as spans of the reconciliation. This covers
+// the resources applied by Manifestival, as it operates on the client.
+func (r *Reconciliation) Client(c client.Client) client.Client {
+	if r == nil {
+		return c
+	}
+	return &tracingClient{Client: c, reconciliation: r}
+}
+
+type tracingClient struct {
+	client.Client
+	reconciliation *Reconciliation
+}
+
+func (c *tracingClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	span := c.startSpan("Get", obj, key.Namespace, key.Name)
+	err := c.Client.Get(ctx, key, obj)
+	endCall(span, err)
+	return err
+}
+
+func (c *tracingClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	span := c.startSpan("List", list, listOpts.Namespace, "")
+	err := c.Client.List(ctx, list, opts...)
+	endCall(span, err)
+	return err
+}
+
+func (c *tracingClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	span := c.startObjectSpan("Create", obj)
+	err := c.Client.Create(ctx, obj, opts...)
+	endCall(span, err)
+	return err
+}
+
+func (c *tracingClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	span := c.startObjectSpan("Update", obj)
+	err := c.Client.Update(ctx, obj, opts...)
+	endCall(span, err)
+	return err
+}
+
+func (c *tracingClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	span := c.startObjectSpan("Patch", obj)
+	err := c.Client.Patch(ctx, obj, patch, opts...)
+	endCall(span, err)
+	return err
+}
+
+func (c *tracingClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	span := c.startObjectSpan("Delete", obj)
+	err := c.Client.Delete(ctx, obj, opts...)
+	endCall(span, err)
+	return err
+}
+
+func (c *tracingClient) Status() client.StatusWriter {
+	return &tracingStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+// tracingStatusWriter records the updates of the status subresource as spans of the reconciliation.
+type tracingStatusWriter struct {
+	client.StatusWriter
+	client *tracingClient
+}
+
+func (w *tracingStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	span := w.client.startObjectSpan("Update status", obj)
+	err := w.StatusWriter.Update(ctx, obj, opts...)
+	endCall(span, err)
+	return err
+}
+
+func (w *tracingStatusWriter) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	span := w.client.startObjectSpan("Patch status", obj)
+	err := w.StatusWriter.Patch(ctx, obj, patch, opts...)
+	endCall(span, err)
+	return err
+}
+
+func (c *tracingClient) startObjectSpan(verb string, obj runtime.Object) *trace.Span {
+	namespace, name := "", ""
+	if accessor, ok := obj.(interface {
+		GetNamespace() string
+		GetName() string
+	}); ok {
+		namespace, name = accessor.GetNamespace(), accessor.GetName()
+	}
+	return c.startSpan(verb, obj, namespace, name)
+}
+
+func (c *tracingClient) startSpan(verb string, obj runtime.Object, namespace, name string) *trace.Span {
+	kind := kindOf(obj)
+	_, span := trace.StartSpan(c.reconciliation.currentParent(), verb+" "+kind, trace.WithSpanKind(trace.SpanKindClient))
+	span.AddAttributes(
+		trace.StringAttribute("k8s.kind", kind),
+		trace.StringAttribute("k8s.namespace", namespace),
+		trace.StringAttribute("k8s.name", name),
+	)
+	return span
+}
+
+// endCall ends the span of an API call. Missing resources are expected, e.g. when Manifestival
+// applies a new resource, and don't fail the span.
+func endCall(span *trace.Span, err error) {
+	if apierrors.IsNotFound(err) {
+		err = nil
+	}
+	endSpan(span, err)
+}
+
+func kindOf(obj runtime.Object) string {
+	if kind := obj.GetObjectKind().GroupVersionKind().Kind; kind != "" {
+		return kind
+	}
+	if gvk, err := apiutil.GVKForObject(obj, scheme.Scheme); err == nil {
+		return gvk.Kind
+	}
+	return fmt.Sprintf("%T", obj)
+}
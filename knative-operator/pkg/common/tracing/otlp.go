@@ -0,0 +1,243 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	// tracesPath is the path of the OTLP/HTTP traces receiver.
+	tracesPath = "/v1/traces"
+	// maxBufferedSpans limits the spans kept while the collector is not reachable.
+	maxBufferedSpans = 2048
+	instrumentation  = "github.com/openshift-knative/serverless-operator/knative-operator"
+)
+
+// Exporter exports OpenCensus spans to an OTLP/HTTP receiver using the JSON encoding. Spans are
+// buffered and sent in batches.
+type Exporter struct {
+	url     string
+	service string
+	client  *http.Client
+
+	mu    sync.Mutex
+	spans []*trace.SpanData
+}
+
+// NewExporter returns an exporter sending the spans of the given service to the OTLP/HTTP
+// receiver at the given base URL.
+func NewExporter(endpoint, service string) *Exporter {
+	return &Exporter{
+		url:     strings.TrimSuffix(endpoint, "/") + tracesPath,
+		service: service,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ExportSpan buffers the span until the next Flush. It implements trace.Exporter.
+func (e *Exporter) ExportSpan(span *trace.SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.spans) >= maxBufferedSpans {
+		// Drop the oldest spans rather than growing without bounds.
+		e.spans = e.spans[1:]
+	}
+	e.spans = append(e.spans, span)
+}
+
+// Run flushes the buffered spans in the given interval until stop is closed and a last time
+// afterwards.
+func (e *Exporter) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := e.Flush(); err != nil {
+				log.Error(err, "Failed to export spans")
+			}
+		case <-stop:
+			if err := e.Flush(); err != nil {
+				log.Error(err, "Failed to export spans")
+			}
+			return
+		}
+	}
+}
+
+// Flush sends the buffered spans to the receiver. The spans are kept for the next Flush if they
+// can't be sent.
+func (e *Exporter) Flush() error {
+	e.mu.Lock()
+	spans := e.spans
+	e.spans = nil
+	e.mu.Unlock()
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(e.request(spans))
+	if err != nil {
+		return fmt.Errorf("failed to marshal spans: %w", err)
+	}
+	if err := e.send(body); err != nil {
+		e.requeue(spans)
+		return fmt.Errorf("failed to send %d spans: %w", len(spans), err)
+	}
+	return nil
+}
+
+func (e *Exporter) send(body []byte) error {
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// requeue puts the given spans back in front of the spans buffered meanwhile. The oldest spans
+// are dropped beyond maxBufferedSpans.
+func (e *Exporter) requeue(spans []*trace.SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(spans, e.spans...)
+	if overflow := len(e.spans) - maxBufferedSpans; overflow > 0 {
+		e.spans = e.spans[overflow:]
+	}
+}
+
+// The types below mirror the JSON encoding of the OTLP ExportTraceServiceRequest.
+
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope  `json:"scope"`
+	Spans []span `json:"spans"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type span struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	Status            status     `json:"status"`
+}
+
+type status struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+// OTLP span kinds and status codes.
+const (
+	spanKindInternal = 1
+	spanKindServer   = 2
+	spanKindClient   = 3
+
+	statusCodeError = 2
+)
+
+func (e *Exporter) request(data []*trace.SpanData) exportRequest {
+	spans := make([]span, 0, len(data))
+	for _, d := range data {
+		s := span{
+			TraceID:           d.TraceID.String(),
+			SpanID:            d.SpanID.String(),
+			Name:              d.Name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(d.StartTime.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(d.EndTime.UnixNano(), 10),
+			Attributes:        attributes(d.Attributes),
+		}
+		if d.ParentSpanID != (trace.SpanID{}) {
+			s.ParentSpanID = d.ParentSpanID.String()
+		}
+		switch d.SpanKind {
+		case trace.SpanKindServer:
+			s.Kind = spanKindServer
+		case trace.SpanKindClient:
+			s.Kind = spanKindClient
+		}
+		if d.Code != trace.StatusCodeOK {
+			s.Status = status{Code: statusCodeError, Message: d.Message}
+		}
+		spans = append(spans, s)
+	}
+	return exportRequest{ResourceSpans: []resourceSpans{{
+		Resource:   resource{Attributes: attributes(map[string]interface{}{"service.name": e.service})},
+		ScopeSpans: []scopeSpans{{Scope: scope{Name: instrumentation}, Spans: spans}},
+	}}}
+}
+
+func attributes(attrs map[string]interface{}) []keyValue {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	kvs := make([]keyValue, 0, len(attrs))
+	for _, key := range keys {
+		var v anyValue
+		switch value := attrs[key].(type) {
+		case string:
+			v.StringValue = &value
+		case bool:
+			v.BoolValue = &value
+		case int64:
+			s := strconv.FormatInt(value, 10)
+			v.IntValue = &s
+		case float64:
+			v.DoubleValue = &value
+		default:
+			s := fmt.Sprint(value)
+			v.StringValue = &s
+		}
+		kvs = append(kvs, keyValue{Key: key, Value: v})
+	}
+	return kvs
+}
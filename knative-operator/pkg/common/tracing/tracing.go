@@ -0,0 +1,134 @@
+// Package tracing traces the reconciliations of the operator with OpenCensus and exports the
+// spans to an OpenTelemetry collector via OTLP. Tracing is disabled unless an endpoint is
+// configured through the OTEL_EXPORTER_OTLP_ENDPOINT environment variable.
+package tracing
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"go.opencensus.io/trace"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// EndpointEnvKey is the base URL of the OTLP/HTTP receiver of the collector, e.g.
+	// "http://otel-collector.observability:4318". Tracing is disabled if it's empty.
+	EndpointEnvKey = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	// ServiceNameEnvKey overrides the service name reported with the spans.
+	ServiceNameEnvKey = "OTEL_SERVICE_NAME"
+
+	defaultServiceName = "knative-openshift"
+	exportInterval     = 5 * time.Second
+)
+
+var log = logf.Log.WithName("knative").WithName("openshift").WithName("tracing")
+
+// Setup enables tracing if an OTLP endpoint is configured and exports the spans until the
+// given channel is closed. Otherwise, no spans are sampled at all.
+func Setup(stop <-chan struct{}) {
+	endpoint := os.Getenv(EndpointEnvKey)
+	if endpoint == "" {
+		trace.ApplyConfig(trace.Config{DefaultSampler: trace.NeverSample()})
+		return
+	}
+	service := os.Getenv(ServiceNameEnvKey)
+	if service == "" {
+		service = defaultServiceName
+	}
+
+	exporter := NewExporter(endpoint, service)
+	trace.RegisterExporter(exporter)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
+	go exporter.Run(exportInterval, stop)
+	log.Info("Tracing enabled", "endpoint", endpoint, "service", service)
+}
+
+// Reconciliation traces a single reconciliation. Its stages and the API calls made through its
+// Client are recorded as child spans. All methods can be called on a nil Reconciliation.
+type Reconciliation struct {
+	root    *trace.Span
+	rootCtx context.Context
+
+	mu     sync.Mutex
+	parent context.Context
+}
+
+// StartReconciliation starts the trace of the reconciliation of the given resource.
+func StartReconciliation(kind string, key types.NamespacedName) *Reconciliation {
+	ctx, span := trace.StartSpan(context.Background(), "Reconcile "+kind)
+	span.AddAttributes(
+		trace.StringAttribute("k8s.kind", kind),
+		trace.StringAttribute("k8s.namespace", key.Namespace),
+		trace.StringAttribute("k8s.name", key.Name),
+	)
+	return &Reconciliation{root: span, rootCtx: ctx, parent: ctx}
+}
+
+// StartStage starts the span of a stage. The API calls made until the returned function is
+// called are recorded as its children. An error passed to the returned function marks both the
+// stage and the reconciliation as failed.
+func (r *Reconciliation) StartStage(name string) func(error) {
+	if r == nil {
+		return func(error) {}
+	}
+	ctx, span := trace.StartSpan(r.rootCtx, "Stage "+name)
+	r.setParent(ctx)
+	return func(err error) {
+		endSpan(span, err)
+		if err != nil {
+			r.root.SetStatus(errorStatus(err))
+		}
+		r.setParent(r.rootCtx)
+	}
+}
+
+// Apply records the application of a manifest by the given function as a span of the current
+// stage. The API calls made for the resources of the manifest are recorded as its children.
+func (r *Reconciliation) Apply(name string, apply func() error) error {
+	if r == nil {
+		return apply()
+	}
+	parent := r.currentParent()
+	ctx, span := trace.StartSpan(parent, "Apply "+name)
+	r.setParent(ctx)
+	defer r.setParent(parent)
+
+	err := apply()
+	endSpan(span, err)
+	return err
+}
+
+// End ends the trace of the reconciliation.
+func (r *Reconciliation) End() {
+	if r == nil {
+		return
+	}
+	r.root.End()
+}
+
+func (r *Reconciliation) setParent(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.parent = ctx
+}
+
+func (r *Reconciliation) currentParent() context.Context {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.parent
+}
+
+func endSpan(span *trace.Span, err error) {
+	if err != nil {
+		span.SetStatus(errorStatus(err))
+	}
+	span.End()
+}
+
+func errorStatus(err error) trace.Status {
+	return trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()}
+}
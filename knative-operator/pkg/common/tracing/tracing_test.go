@@ -0,0 +1,136 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opencensus.io/trace"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestExportReconciliation(t *testing.T) {
+	// The collector stand-in records the requests sent to the OTLP/HTTP traces receiver.
+	var requests []exportRequest
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != tracesPath || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Got request to %s with content type %q", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		var req exportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		requests = append(requests, req)
+	}))
+	defer collector.Close()
+
+	exporter := NewExporter(collector.URL, "test")
+	trace.RegisterExporter(exporter)
+	defer trace.UnregisterExporter(exporter)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
+	defer trace.ApplyConfig(trace.Config{DefaultSampler: trace.NeverSample()})
+
+	reconciliation := StartReconciliation("KnativeServing", types.NamespacedName{Namespace: "knative-serving", Name: "knative-serving"})
+	api := reconciliation.Client(fake.NewFakeClient())
+	endStage := reconciliation.StartStage("kourier")
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "knative-serving", Name: "test"}}
+	if err := reconciliation.Apply("kourier", func() error {
+		return api.Create(context.TODO(), cm)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := api.Status().Update(context.TODO(), cm); err != nil {
+		t.Fatal(err)
+	}
+	endStage(errors.New("deployments not ready"))
+	reconciliation.End()
+
+	if err := exporter.Flush(); err != nil {
+		t.Fatalf("Flush() = %v", err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("Got %d requests, want 1", len(requests))
+	}
+	spans := map[string]span{}
+	for _, s := range requests[0].ResourceSpans[0].ScopeSpans[0].Spans {
+		spans[s.Name] = s
+	}
+	root, stage, apply := spans["Reconcile KnativeServing"], spans["Stage kourier"], spans["Apply kourier"]
+	create, status := spans["Create ConfigMap"], spans["Update status ConfigMap"]
+	if root.SpanID == "" || stage.SpanID == "" || apply.SpanID == "" || create.SpanID == "" || status.SpanID == "" {
+		t.Fatalf("Missing spans, got %v", spans)
+	}
+	if root.ParentSpanID != "" || stage.ParentSpanID != root.SpanID || apply.ParentSpanID != stage.SpanID ||
+		create.ParentSpanID != apply.SpanID || status.ParentSpanID != stage.SpanID {
+		t.Errorf("Spans not nested as expected: %v", spans)
+	}
+	if create.Kind != spanKindClient {
+		t.Errorf("Create span kind = %d, want %d", create.Kind, spanKindClient)
+	}
+	if stage.Status.Code != statusCodeError || root.Status.Code != statusCodeError {
+		t.Errorf("Failed stage not reflected in status, stage: %v, root: %v", stage.Status, root.Status)
+	}
+	if create.Status.Code != 0 {
+		t.Errorf("Create span status = %v, want ok", create.Status)
+	}
+	attrs := requests[0].ResourceSpans[0].Resource.Attributes
+	if len(attrs) != 1 || attrs[0].Key != "service.name" || *attrs[0].Value.StringValue != "test" {
+		t.Errorf("Resource attributes = %v, want the service name", attrs)
+	}
+}
+
+func TestFlushFailure(t *testing.T) {
+	available := false
+	var received []span
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !available {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var req exportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		received = append(received, req.ResourceSpans[0].ScopeSpans[0].Spans...)
+	}))
+	defer collector.Close()
+
+	exporter := NewExporter(collector.URL, "test")
+	exporter.ExportSpan(&trace.SpanData{Name: "first"})
+	if err := exporter.Flush(); err == nil {
+		t.Fatal("Flush() = nil, want an error while the collector is unavailable")
+	}
+
+	// The spans that failed to be sent are sent along with the new ones.
+	available = true
+	exporter.ExportSpan(&trace.SpanData{Name: "second"})
+	if err := exporter.Flush(); err != nil {
+		t.Fatalf("Flush() = %v", err)
+	}
+	if len(received) != 2 || received[0].Name != "first" || received[1].Name != "second" {
+		t.Errorf("Received spans = %v, want first and second", received)
+	}
+}
+
+func TestNilReconciliation(t *testing.T) {
+	var r *Reconciliation
+	api := fake.NewFakeClient()
+	if r.Client(api) != api {
+		t.Error("Client() of a nil Reconciliation should return the client as is")
+	}
+	r.StartStage("test")(nil)
+	applied := false
+	if err := r.Apply("test", func() error {
+		applied = true
+		return nil
+	}); err != nil || !applied {
+		t.Errorf("Apply() = %v, applied %v, want the manifest applied", err, applied)
+	}
+	r.End()
+}
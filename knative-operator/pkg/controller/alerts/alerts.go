@@ -0,0 +1,179 @@
+package alerts
+
+import (
+	"fmt"
+	"os"
+
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	mfc "github.com/manifestival/controller-runtime-client"
+	mf "github.com/manifestival/manifestival"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = common.Log.WithName("alerts")
+
+const (
+	ServingAlertsPathEnvVar  = "SERVING_ALERTS_MANIFEST_PATH"
+	EventingAlertsPathEnvVar = "EVENTING_ALERTS_MANIFEST_PATH"
+	KafkaAlertsPathEnvVar    = "KAFKA_ALERTS_MANIFEST_PATH"
+
+	// AlertAnnotationPrefix followed by the name of an alert is the annotation overriding the
+	// alert on an instance. The value is either AlertDisabled or the severity of the alert.
+	AlertAnnotationPrefix = "alert.serverless.openshift.io/"
+	// AlertDisabled disables an alert.
+	AlertDisabled = "disabled"
+)
+
+var defaultPaths = map[string]string{
+	ServingAlertsPathEnvVar:  "deploy/resources/alerts/serving-alerts.yaml",
+	EventingAlertsPathEnvVar: "deploy/resources/alerts/eventing-alerts.yaml",
+	KafkaAlertsPathEnvVar:    "deploy/resources/alerts/kafka-alerts.yaml",
+}
+
+// severities are the severities an alert can be overridden with.
+var severities = map[string]bool{"critical": true, "warning": true, "info": true}
+
+// Path returns the path of the alerts manifest configured by the given env var.
+func Path(envVar string) string {
+	if path := os.Getenv(envVar); path != "" {
+		return path
+	}
+	return defaultPaths[envVar]
+}
+
+// Apply applies the PrometheusRules of the given manifest into the namespace of the operator,
+// which is monitored by the cluster monitoring, annotated with the given owner annotations. The
+// alerts are overridden by the annotations of the instance. Nothing is applied if PrometheusRules
+// are not served by the cluster.
+func Apply(path string, instance metav1.Object, owner map[string]string, api client.Client) error {
+	manifest, err := manifest(path, owner, api)
+	if err != nil {
+		return fmt.Errorf("failed to load alerts manifest: %w", err)
+	}
+	if manifest, err = manifest.Transform(overrides(instance.GetAnnotations())); err != nil {
+		return fmt.Errorf("failed to override alerts: %w", err)
+	}
+	log.Info("Installing alerts", "path", path)
+	if err := manifest.Apply(); err != nil {
+		if meta.IsNoMatchError(err) {
+			log.Info("PrometheusRules not served by the cluster, skipping the alerts")
+			return nil
+		}
+		return fmt.Errorf("failed to apply alerts manifest: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes the PrometheusRules of the given manifest.
+func Delete(path string, owner map[string]string, api client.Client) error {
+	manifest, err := manifest(path, owner, api)
+	if err != nil {
+		return fmt.Errorf("failed to load alerts manifest: %w", err)
+	}
+	log.Info("Deleting alerts", "path", path)
+	if err := manifest.Delete(); err != nil && !meta.IsNoMatchError(err) {
+		return fmt.Errorf("failed to delete alerts manifest: %w", err)
+	}
+	return nil
+}
+
+// Watch makes the controller watch the PrometheusRules if they are served by the cluster.
+func Watch(c controller.Controller, mgr manager.Manager, h handler.EventHandler) error {
+	gvk, err := apiutil.GVKForObject(&monitoringv1.PrometheusRule{}, mgr.GetScheme())
+	if err != nil {
+		return err
+	}
+	if _, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+		if meta.IsNoMatchError(err) {
+			log.Info("PrometheusRules not served by the cluster, not watching them")
+			return nil
+		}
+		return err
+	}
+	return c.Watch(&source.Kind{Type: &monitoringv1.PrometheusRule{}}, h)
+}
+
+// Manifest returns the alerts manifest for the given instance, as it's applied.
+func Manifest(path string, instance metav1.Object, owner map[string]string, api client.Client) (mf.Manifest, error) {
+	manifest, err := manifest(path, owner, api)
+	if err != nil {
+		return mf.Manifest{}, err
+	}
+	return manifest.Transform(overrides(instance.GetAnnotations()))
+}
+
+// manifest returns the alerts manifest
+func manifest(path string, owner map[string]string, apiclient client.Client) (mf.Manifest, error) {
+	manifest, err := mfc.NewManifest(path, apiclient, mf.UseLogger(log.WithName("mf")))
+	if err != nil {
+		return mf.Manifest{}, fmt.Errorf("failed to read alerts manifest: %w", err)
+	}
+	// set owner to watch events.
+	transforms := []mf.Transformer{mf.InjectNamespace(os.Getenv(common.NamespaceEnvKey)), common.SetAnnotations(owner)}
+	manifest, err = manifest.Transform(transforms...)
+	if err != nil {
+		return mf.Manifest{}, fmt.Errorf("failed to transform alerts manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// overrides removes the disabled alerts and sets the severity of the alerts overridden by the
+// given annotations. Groups left without rules are removed.
+func overrides(annotations map[string]string) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "PrometheusRule" {
+			return nil
+		}
+		groups, _, err := unstructured.NestedSlice(u.Object, "spec", "groups")
+		if err != nil {
+			return err
+		}
+		keptGroups := make([]interface{}, 0, len(groups))
+		for _, g := range groups {
+			group, ok := g.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("invalid rule group in %s: %v", u.GetName(), g)
+			}
+			rules, _, err := unstructured.NestedSlice(group, "rules")
+			if err != nil {
+				return err
+			}
+			keptRules := make([]interface{}, 0, len(rules))
+			for _, r := range rules {
+				rule, ok := r.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("invalid rule in %s: %v", u.GetName(), r)
+				}
+				name, _, _ := unstructured.NestedString(rule, "alert")
+				value := annotations[AlertAnnotationPrefix+name]
+				switch {
+				case name == "" || value == "":
+				case value == AlertDisabled:
+					continue
+				case severities[value]:
+					if err := unstructured.SetNestedField(rule, value, "labels", "severity"); err != nil {
+						return err
+					}
+				default:
+					log.Info("Ignoring invalid alert override", "alert", name, "value", value)
+				}
+				keptRules = append(keptRules, rule)
+			}
+			if len(keptRules) == 0 {
+				continue
+			}
+			group["rules"] = keptRules
+			keptGroups = append(keptGroups, group)
+		}
+		return unstructured.SetNestedSlice(u.Object, keptGroups, "spec", "groups")
+	}
+}
@@ -0,0 +1,136 @@
+package alerts
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	servingPath = "../../../deploy/resources/alerts/serving-alerts.yaml"
+	namespace   = "openshift-serverless"
+)
+
+var owner = map[string]string{
+	common.ServingOwnerName:      "knative-serving",
+	common.ServingOwnerNamespace: "knative-serving",
+}
+
+// noRulesClient behaves like a client of a cluster not serving PrometheusRules.
+type noRulesClient struct {
+	client.Client
+}
+
+func (c noRulesClient) Get(_ context.Context, _ client.ObjectKey, obj runtime.Object) error {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	return &meta.NoKindMatchError{GroupKind: gvk.GroupKind(), SearchedVersions: []string{gvk.Version}}
+}
+
+func (c noRulesClient) Delete(_ context.Context, obj runtime.Object, _ ...client.DeleteOption) error {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	return &meta.NoKindMatchError{GroupKind: gvk.GroupKind(), SearchedVersions: []string{gvk.Version}}
+}
+
+func TestApplyAndDelete(t *testing.T) {
+	os.Setenv(common.NamespaceEnvKey, namespace)
+	defer os.Unsetenv(common.NamespaceEnvKey)
+
+	instance := &metav1.ObjectMeta{Annotations: map[string]string{
+		AlertAnnotationPrefix + "KnativeServingWebhookErrors":    AlertDisabled,
+		AlertAnnotationPrefix + "KnativeServingReconcileFailing": "critical",
+		AlertAnnotationPrefix + "KnativeServingDown":             "page-me",
+	}}
+	api := fake.NewFakeClient()
+	if err := Apply(servingPath, instance, owner, api); err != nil {
+		t.Fatalf("Apply() = %v", err)
+	}
+
+	rule := get(t, api)
+	if rule == nil {
+		t.Fatal("PrometheusRule was not created")
+	}
+	for k, v := range owner {
+		if got := rule.GetAnnotations()[k]; got != v {
+			t.Errorf("Annotation %q = %q, want %q", k, got, v)
+		}
+	}
+	want := map[string]string{
+		"KnativeServingDown":               "critical",
+		"KnativeServingWebhookLatencyHigh": "warning",
+		"KnativeServingReconcileFailing":   "critical",
+	}
+	if got := alertSeverities(t, rule); !equal(got, want) {
+		t.Errorf("Got alerts %v, want %v", got, want)
+	}
+
+	if err := Delete(servingPath, owner, api); err != nil {
+		t.Fatalf("Delete() = %v", err)
+	}
+	if get(t, api) != nil {
+		t.Error("PrometheusRule was not deleted")
+	}
+}
+
+func TestAlertsNotServed(t *testing.T) {
+	api := noRulesClient{fake.NewFakeClient()}
+	if err := Apply(servingPath, &metav1.ObjectMeta{}, owner, api); err != nil {
+		t.Errorf("Apply() = %v, want the alerts to be skipped", err)
+	}
+	if err := Delete(servingPath, owner, api); err != nil {
+		t.Errorf("Delete() = %v, want the alerts to be skipped", err)
+	}
+}
+
+func get(t *testing.T, api client.Client) *unstructured.Unstructured {
+	t.Helper()
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("monitoring.coreos.com/v1")
+	u.SetKind("PrometheusRule")
+	err := api.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: "knative-serving-alerts"}, u)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatalf("Failed to get PrometheusRule: %v", err)
+	}
+	return u
+}
+
+// alertSeverities returns the severity of the alerts of the rule by name.
+func alertSeverities(t *testing.T, rule *unstructured.Unstructured) map[string]string {
+	t.Helper()
+	groups, _, err := unstructured.NestedSlice(rule.Object, "spec", "groups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, g := range groups {
+		rules, _, _ := unstructured.NestedSlice(g.(map[string]interface{}), "rules")
+		for _, r := range rules {
+			name, _, _ := unstructured.NestedString(r.(map[string]interface{}), "alert")
+			got[name], _, _ = unstructured.NestedString(r.(map[string]interface{}), "labels", "severity")
+		}
+	}
+	return got
+}
+
+func equal(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || v != w {
+			return false
+		}
+	}
+	return true
+}
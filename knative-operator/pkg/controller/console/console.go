@@ -0,0 +1,121 @@
+package console
+
+import (
+	"fmt"
+	"os"
+
+	mfc "github.com/manifestival/controller-runtime-client"
+	mf "github.com/manifestival/manifestival"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = common.Log.WithName("console")
+
+const (
+	ServingConsolePathEnvVar  = "SERVING_CONSOLE_MANIFEST_PATH"
+	EventingConsolePathEnvVar = "EVENTING_CONSOLE_MANIFEST_PATH"
+	KafkaConsolePathEnvVar    = "KAFKA_CONSOLE_MANIFEST_PATH"
+)
+
+var defaultPaths = map[string]string{
+	ServingConsolePathEnvVar:  "deploy/resources/console/serving-console.yaml",
+	EventingConsolePathEnvVar: "deploy/resources/console/eventing-console.yaml",
+	KafkaConsolePathEnvVar:    "deploy/resources/console/kafka-console.yaml",
+}
+
+// Path returns the path of the console resources manifest configured by the given env var.
+func Path(envVar string) string {
+	if path := os.Getenv(envVar); path != "" {
+		return path
+	}
+	return defaultPaths[envVar]
+}
+
+// Apply applies the console resources (ConsoleYAMLSamples, ConsoleQuickStarts, ...) of the
+// given manifest, annotated with the given owner annotations. Kinds that are not served by
+// the cluster, like ConsoleQuickStarts on older OpenShift versions, are skipped.
+func Apply(path string, owner map[string]string, api client.Client) error {
+	manifest, err := manifest(path, owner, api)
+	if err != nil {
+		return fmt.Errorf("failed to load console manifest: %w", err)
+	}
+	log.Info("Installing console resources", "path", path)
+	return forEachKind(manifest, func(m mf.Manifest) error {
+		return m.Apply()
+	})
+}
+
+// Delete deletes the console resources of the given manifest.
+func Delete(path string, owner map[string]string, api client.Client) error {
+	manifest, err := manifest(path, owner, api)
+	if err != nil {
+		return fmt.Errorf("failed to load console manifest: %w", err)
+	}
+	log.Info("Deleting console resources", "path", path)
+	return forEachKind(manifest, func(m mf.Manifest) error {
+		return m.Delete()
+	})
+}
+
+// Watch makes the controller watch all kinds of the console resources of the given manifest
+// that are served by the cluster.
+func Watch(path string, c controller.Controller, mgr manager.Manager, h handler.EventHandler) error {
+	manifest, err := mf.NewManifest(path)
+	if err != nil {
+		return fmt.Errorf("failed to load console manifest: %w", err)
+	}
+	for gvk, t := range common.BuildGVKToResourceMap(manifest) {
+		if _, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			if meta.IsNoMatchError(err) {
+				log.Info("Console resource not served by the cluster, not watching it", "kind", gvk.Kind)
+				continue
+			}
+			return err
+		}
+		if err := c.Watch(&source.Kind{Type: t}, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forEachKind calls fn with the resources of the manifest grouped by kind and skips the
+// kinds that are not served by the cluster.
+func forEachKind(manifest mf.Manifest, fn func(mf.Manifest) error) error {
+	for gvk := range common.BuildGVKToResourceMap(manifest) {
+		err := fn(manifest.Filter(mf.ByGVK(gvk)))
+		if meta.IsNoMatchError(err) {
+			log.Info("Console resource not served by the cluster, skipping it", "kind", gvk.Kind)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to process %s resources: %w", gvk.Kind, err)
+		}
+	}
+	return nil
+}
+
+// Manifest returns the console resources manifest with the given owner annotations, as it's applied.
+func Manifest(path string, owner map[string]string, api client.Client) (mf.Manifest, error) {
+	return manifest(path, owner, api)
+}
+
+// manifest returns the console resources manifest
+func manifest(path string, owner map[string]string, apiclient client.Client) (mf.Manifest, error) {
+	manifest, err := mfc.NewManifest(path, apiclient, mf.UseLogger(log.WithName("mf")))
+	if err != nil {
+		return mf.Manifest{}, fmt.Errorf("failed to read console manifest: %w", err)
+	}
+	// set owner to watch events.
+	manifest, err = manifest.Transform(common.SetAnnotations(owner))
+	if err != nil {
+		return mf.Manifest{}, fmt.Errorf("failed to transform console resources manifest: %w", err)
+	}
+	return manifest, nil
+}
@@ -0,0 +1,114 @@
+package console
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const servingPath = "../../../deploy/resources/console/serving-console.yaml"
+
+var owner = map[string]string{
+	"serving.knative.openshift.io/ownerName":      "knative-serving",
+	"serving.knative.openshift.io/ownerNamespace": "knative-serving",
+}
+
+// noQuickStartsClient behaves like a client of a cluster not serving ConsoleQuickStarts.
+type noQuickStartsClient struct {
+	client.Client
+}
+
+func (c noQuickStartsClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if err := noMatch(obj); err != nil {
+		return err
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c noQuickStartsClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	if err := noMatch(obj); err != nil {
+		return err
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c noQuickStartsClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	if err := noMatch(obj); err != nil {
+		return err
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func noMatch(obj runtime.Object) error {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if gvk.Kind == "ConsoleQuickStart" {
+		return &meta.NoKindMatchError{GroupKind: gvk.GroupKind(), SearchedVersions: []string{gvk.Version}}
+	}
+	return nil
+}
+
+func TestApplyAndDelete(t *testing.T) {
+	tests := []struct {
+		name            string
+		client          client.Client
+		wantQuickStarts bool
+	}{{
+		name:            "all console resources served",
+		client:          fake.NewFakeClient(),
+		wantQuickStarts: true,
+	}, {
+		name:   "quick starts not served",
+		client: noQuickStartsClient{fake.NewFakeClient()},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := Apply(servingPath, owner, test.client); err != nil {
+				t.Fatalf("Apply() = %v", err)
+			}
+
+			sample := get(t, test.client, "ConsoleYAMLSample", "serverless-knative-service")
+			if sample == nil {
+				t.Fatal("ConsoleYAMLSample was not created")
+			}
+			for k, v := range owner {
+				if got := sample.GetAnnotations()[k]; got != v {
+					t.Errorf("Annotation %q = %q, want %q", k, got, v)
+				}
+			}
+			if test.wantQuickStarts {
+				if get(t, test.client, "ConsoleQuickStart", "serverless-application") == nil {
+					t.Error("ConsoleQuickStart was not created")
+				}
+			}
+
+			if err := Delete(servingPath, owner, test.client); err != nil {
+				t.Fatalf("Delete() = %v", err)
+			}
+			if get(t, test.client, "ConsoleYAMLSample", "serverless-knative-service") != nil {
+				t.Error("ConsoleYAMLSample was not deleted")
+			}
+		})
+	}
+}
+
+func get(t *testing.T, api client.Client, kind, name string) *unstructured.Unstructured {
+	t.Helper()
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(schema.GroupVersionKind{Group: "console.openshift.io", Version: "v1", Kind: kind})
+	err := api.Get(context.TODO(), client.ObjectKey{Name: name}, u)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatalf("Failed to get %s %s: %v", kind, name, err)
+	}
+	return u
+}
@@ -1,17 +1,20 @@
 package dashboard
 
 import (
-	"context"
 	"fmt"
+	"strings"
+	"text/template"
 
 	operatorv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
 
 	mfc "github.com/manifestival/controller-runtime-client"
 	mf "github.com/manifestival/manifestival"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
-	corev1 "k8s.io/api/core/v1"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
 var log = common.Log.WithName("dashboard")
@@ -19,70 +22,122 @@ var log = common.Log.WithName("dashboard")
 const ConfigManagedNamespace = "openshift-config-managed"
 
 const ServingDashboardPathEnvVar = "SERVING_DASHBOARD_MANIFEST_PATH"
+const ServingSLODashboardPathEnvVar = "SERVING_SLO_DASHBOARD_MANIFEST_PATH"
 const EventingBrokerDashboardPathEnvVar = "EVENTING_BROKER_DASHBOARD_MANIFEST_PATH"
 const EventingSourceDashboardPathEnvVar = "EVENTING_SOURCE_DASHBOARD_MANIFEST_PATH"
 
-// Apply applies dashboard resources.
+// TemplateData are the variables the dashboard manifests are rendered with, taken from the owning
+// instance. The data of the dashboard ConfigMaps are templates with "[[" and "]]" as delimiters, as
+// Grafana uses braces in legends, e.g. namespace="[[ .Namespace ]]".
+type TemplateData struct {
+	// Namespace is the namespace of the instance, i.e. of the Serving or Eventing control plane.
+	Namespace string
+	// Component is the Knative component of the instance, "serving" or "eventing".
+	Component string
+	// Instance is the name of the instance.
+	Instance string
+}
+
+// Apply applies dashboard resources to the configured targets, see common.DashboardTargets, and
+// removes them from the other targets.
 func Apply(path string, instance operatorv1alpha1.KComponent, api client.Client) error {
-	err := api.Get(context.TODO(), client.ObjectKey{Name: ConfigManagedNamespace}, &corev1.Namespace{})
-	if apierrors.IsNotFound(err) {
-		log.Info(fmt.Sprintf("namespace %q not found. Skipping to create dashboard.", ConfigManagedNamespace))
-		return nil
-	} else if err != nil {
-		return fmt.Errorf("failed to get namespace %q: %w", ConfigManagedNamespace, err)
-	}
-	manifest, err := manifest(path, getAnnotationsFromInstance(instance), api)
+	manifest, others, err := manifests(path, instance, api)
 	if err != nil {
 		return fmt.Errorf("failed to load dashboard manifest: %w", err)
 	}
 	log.Info("Installing dashboard ", "path:", path)
-	if err := manifest.Apply(); err != nil {
+	if err := common.ApplyDashboards(manifest); err != nil {
 		return fmt.Errorf("failed to apply dashboard manifest: %w", err)
 	}
+	if err := common.DeleteDashboards(others); err != nil {
+		return fmt.Errorf("failed to delete dashboards of other targets: %w", err)
+	}
 	log.Info("Dashboard is ready")
 	return nil
 }
 
-// Delete deletes dashboard resources.
+// Delete deletes dashboard resources of all targets.
 func Delete(path string, instance operatorv1alpha1.KComponent, api client.Client) error {
 	log.Info("Deleting dashboard")
-	manifest, err := manifest(path, getAnnotationsFromInstance(instance), api)
+	manifest, others, err := manifests(path, instance, api)
 	if err != nil {
 		return fmt.Errorf("failed to load dashboard manifest: %w", err)
 	}
 
-	if err := manifest.Delete(); err != nil {
+	if err := common.DeleteDashboards(manifest.Append(others)); err != nil {
 		return fmt.Errorf("failed to delete dashboard manifest: %w", err)
 	}
 	return nil
 }
 
-// manifest returns dashboard deploymnet resources manifest
-func manifest(path string, owner mf.Transformer, apiclient client.Client) (mf.Manifest, error) {
+// Manifest returns the dashboard manifest transformed for the instance, as it's applied.
+func Manifest(path string, instance operatorv1alpha1.KComponent, api client.Client) (mf.Manifest, error) {
+	manifest, _, err := manifests(path, instance, api)
+	return manifest, err
+}
+
+// Watch makes the controller watch the dashboards of the targets other than the console, whose
+// ConfigMaps are watched by the controllers anyway.
+func Watch(c controller.Controller, mgr manager.Manager, h handler.EventHandler) error {
+	return common.WatchGrafanaDashboards(c, mgr, h)
+}
+
+// manifests returns the dashboard resources of the instance for the configured targets and for the other ones.
+func manifests(path string, instance operatorv1alpha1.KComponent, apiclient client.Client) (mf.Manifest, mf.Manifest, error) {
 	manifest, err := mfc.NewManifest(path, apiclient, mf.UseLogger(log.WithName("mf")))
 	if err != nil {
-		return mf.Manifest{}, fmt.Errorf("failed to read dashboard manifest: %w", err)
+		return mf.Manifest{}, mf.Manifest{}, fmt.Errorf("failed to read dashboard manifest: %w", err)
+	}
+	manifest, err = manifest.Transform(renderTemplates(templateData(instance)))
+	if err != nil {
+		return mf.Manifest{}, mf.Manifest{}, fmt.Errorf("failed to render dashboard templates: %w", err)
 	}
+	return common.DashboardManifests(manifest, getAnnotationsFromInstance(instance), apiclient)
+}
 
-	// set owner to watch events.
-	transforms := []mf.Transformer{mf.InjectNamespace(ConfigManagedNamespace), owner}
+// renderTemplates renders the data of the dashboard ConfigMaps as templates.
+func renderTemplates(data TemplateData) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "ConfigMap" {
+			return nil
+		}
+		values, _, err := unstructured.NestedStringMap(u.Object, "data")
+		if err != nil {
+			return err
+		}
+		for key, value := range values {
+			tmpl, err := template.New(key).Delims("[[", "]]").Parse(value)
+			if err != nil {
+				return fmt.Errorf("failed to parse %s of %s: %w", key, u.GetName(), err)
+			}
+			var b strings.Builder
+			if err := tmpl.Execute(&b, data); err != nil {
+				return fmt.Errorf("failed to render %s of %s: %w", key, u.GetName(), err)
+			}
+			values[key] = b.String()
+		}
+		return unstructured.SetNestedStringMap(u.Object, values, "data")
+	}
+}
 
-	manifest, err = manifest.Transform(transforms...)
-	if err != nil {
-		return mf.Manifest{}, fmt.Errorf("failed to transform kn dashboard resources manifest: %w", err)
+func templateData(instance operatorv1alpha1.KComponent) TemplateData {
+	switch v := instance.(type) {
+	case *operatorv1alpha1.KnativeEventing:
+		return TemplateData{Namespace: v.Namespace, Component: "eventing", Instance: v.Name}
+	case *operatorv1alpha1.KnativeServing:
+		return TemplateData{Namespace: v.Namespace, Component: "serving", Instance: v.Name}
 	}
-	return manifest, nil
+	return TemplateData{}
 }
 
 func getAnnotationsFromInstance(instance operatorv1alpha1.KComponent) mf.Transformer {
-	var value interface{} = instance
-	switch v := value.(type) {
-	case operatorv1alpha1.KnativeEventing:
+	switch v := instance.(type) {
+	case *operatorv1alpha1.KnativeEventing:
 		return common.SetAnnotations(map[string]string{
 			common.EventingOwnerName:      v.Name,
 			common.EventingOwnerNamespace: v.Namespace,
 		})
-	case operatorv1alpha1.KnativeServing:
+	case *operatorv1alpha1.KnativeServing:
 		return common.SetAnnotations(map[string]string{
 			common.ServingOwnerName:      v.Name,
 			common.ServingOwnerNamespace: v.Namespace,
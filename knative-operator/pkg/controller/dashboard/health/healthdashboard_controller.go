@@ -2,6 +2,7 @@ package health
 
 import (
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common/tracing"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -29,7 +30,7 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New("health-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("health-controller", mgr, controller.Options{Reconciler: common.ObservedReconciler("health-dashboard", r)})
 	if err != nil {
 		return err
 	}
@@ -38,7 +39,7 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	if err != nil {
 		return err
 	}
-	return nil
+	return common.WatchGrafanaDashboards(c, mgr, common.EnqueueRequestByOwnerAnnotations(common.ServerlessOperatorOwnerName, common.ServerlessOperatorOwnerNamespace), skipCreatePredicate{})
 }
 
 // blank assignment to verify that ReconcileHealthDashboard implements reconcile.Reconciler
@@ -56,9 +57,14 @@ type ReconcileHealthDashboard struct {
 func (r *ReconcileHealthDashboard) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling HealthDashboard")
+	trace := tracing.StartReconciliation("HealthDashboard", request.NamespacedName)
+	defer trace.End()
+
 	// in any case restore the current health dashboard, since the configmap shouldnt
 	// be modified, if the configmap has not changed this will not trigger a real update
-	err := common.InstallHealthDashboard(r.client)
+	endStage := trace.StartStage("dashboard")
+	err := common.InstallHealthDashboard(trace.Client(r.client))
+	endStage(err)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
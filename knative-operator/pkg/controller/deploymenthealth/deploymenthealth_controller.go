@@ -0,0 +1,96 @@
+package deploymenthealth
+
+import (
+	"context"
+	"sync"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = common.Log.WithName("deployment-health-controller")
+
+// Add creates a new Controller reporting the readiness of the deployments of the Knative
+// components and adds it to the Manager. The Manager will set fields on the Controller and Start
+// it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) *ReconcileDeploymentHealth {
+	return &ReconcileDeploymentHealth{client: mgr.GetClient(), components: map[types.NamespacedName]string{}}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r *ReconcileDeploymentHealth) error {
+	// Create a new controller
+	c, err := controller.New("deployment-health-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch the deployments of the Knative components only. Updates removing the owner are
+	// processed too, to drop the gauge of the deployment.
+	return c.Watch(&source.Kind{Type: &appsv1.Deployment{}}, &handler.EnqueueRequestForObject{}, predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool { return common.DeploymentComponent(e.Meta) != "" },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return common.DeploymentComponent(e.MetaOld) != "" || common.DeploymentComponent(e.MetaNew) != ""
+		},
+		DeleteFunc:  func(e event.DeleteEvent) bool { return common.DeploymentComponent(e.Meta) != "" },
+		GenericFunc: func(e event.GenericEvent) bool { return common.DeploymentComponent(e.Meta) != "" },
+	})
+}
+
+// blank assignment to verify that ReconcileDeploymentHealth implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileDeploymentHealth{}
+
+// ReconcileDeploymentHealth reports the readiness of the deployments of the Knative components
+type ReconcileDeploymentHealth struct {
+	client client.Client
+
+	// components remembers the component of the reported deployments, to remove their gauge
+	// once they are deleted.
+	mu         sync.Mutex
+	components map[types.NamespacedName]string
+}
+
+// Reconcile updates the readiness gauge of a deployment.
+func (r *ReconcileDeploymentHealth) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	dep := &appsv1.Deployment{}
+	if err := r.client.Get(context.TODO(), request.NamespacedName, dep); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		dep = nil
+	}
+
+	component := ""
+	if dep != nil {
+		component = common.DeploymentComponent(dep)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if previous, ok := r.components[request.NamespacedName]; ok && previous != component {
+		common.ForgetDeploymentReadiness(previous, request.Namespace, request.Name)
+		delete(r.components, request.NamespacedName)
+	}
+	if component == "" {
+		return reconcile.Result{}, nil
+	}
+	common.ReportDeploymentReadiness(component, dep)
+	r.components[request.NamespacedName] = component
+	log.V(1).Info("Reported deployment readiness", "deployment", request.NamespacedName, "component", component, "ready", common.IsDeploymentReady(dep))
+	return reconcile.Result{}, nil
+}
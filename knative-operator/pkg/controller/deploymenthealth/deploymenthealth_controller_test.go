@@ -0,0 +1,127 @@
+package deploymenthealth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestDeploymentComponent(t *testing.T) {
+	isController := true
+	tests := []struct {
+		name string
+		meta metav1.ObjectMeta
+		want string
+	}{{
+		name: "serving",
+		meta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{{Kind: "KnativeServing", Controller: &isController}}},
+		want: "serving",
+	}, {
+		name: "eventing",
+		meta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{{Kind: "KnativeEventing", Controller: &isController}}},
+		want: "eventing",
+	}, {
+		name: "kourier",
+		meta: metav1.ObjectMeta{Annotations: map[string]string{common.ServingOwnerName: "knative-serving"}},
+		want: "serving",
+	}, {
+		name: "kafka",
+		meta: metav1.ObjectMeta{Annotations: map[string]string{common.KafkaOwnerName: "knative-kafka"}},
+		want: "kafka",
+	}, {
+		name: "not a controller",
+		meta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{{Kind: "KnativeServing"}}},
+	}, {
+		name: "unmanaged",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := common.DeploymentComponent(&test.meta); got != test.want {
+				t.Errorf("DeploymentComponent() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	replicas := int32(2)
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "kafka-ch-controller",
+			Namespace:   "knative-eventing",
+			Generation:  2,
+			Annotations: map[string]string{common.KafkaOwnerName: "knative-kafka"},
+		},
+		Spec:   appsv1.DeploymentSpec{Replicas: &replicas},
+		Status: appsv1.DeploymentStatus{ObservedGeneration: 2, ReadyReplicas: 1},
+	}
+	key := types.NamespacedName{Namespace: dep.Namespace, Name: dep.Name}
+	api := fake.NewFakeClient(dep)
+	r := &ReconcileDeploymentHealth{client: api, components: map[types.NamespacedName]string{}}
+
+	reconcileAndExpect := func(want map[string]float64) {
+		t.Helper()
+		if _, err := r.Reconcile(reconcile.Request{NamespacedName: key}); err != nil {
+			t.Fatalf("Reconcile() = %v", err)
+		}
+		if got := readiness(t); !equal(got, want) {
+			t.Errorf("Got readiness %v, want %v", got, want)
+		}
+	}
+
+	reconcileAndExpect(map[string]float64{"kafka/knative-eventing/kafka-ch-controller": 0})
+
+	dep.Status.ReadyReplicas = 2
+	if err := api.Update(context.TODO(), dep); err != nil {
+		t.Fatal(err)
+	}
+	reconcileAndExpect(map[string]float64{"kafka/knative-eventing/kafka-ch-controller": 1})
+
+	if err := api.Delete(context.TODO(), dep); err != nil {
+		t.Fatal(err)
+	}
+	reconcileAndExpect(map[string]float64{})
+}
+
+// readiness returns the values of the readiness gauge by component/namespace/deployment.
+func readiness(t *testing.T) map[string]float64 {
+	t.Helper()
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != "knative_component_deployment_ready" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			values[labels["component"]+"/"+labels["namespace"]+"/"+labels["deployment"]] = m.GetGauge().GetValue()
+		}
+	}
+	return values
+}
+
+func equal(a, b map[string]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || v != w {
+			return false
+		}
+	}
+	return true
+}
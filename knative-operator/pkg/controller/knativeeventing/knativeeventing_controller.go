@@ -5,19 +5,28 @@ import (
 	"fmt"
 	"os"
 
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common/telemetry"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common/tracing"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/alerts"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/console"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/dashboard"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	messagingv1 "knative.dev/eventing/pkg/apis/messaging/v1"
 	eventingsourcesv1beta1 "knative.dev/eventing/pkg/apis/sources/v1beta1"
 	eventingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -34,6 +43,11 @@ var (
 		&eventingsourcesv1beta1.PingSource{},
 		&eventingsourcesv1beta1.ApiServerSource{},
 		&eventingsourcesv1beta1.SinkBinding{},
+		&eventingsourcesv1beta1.ContainerSource{},
+		&eventingv1.Broker{},
+		&eventingv1.Trigger{},
+		&messagingv1.InMemoryChannel{},
+		&messagingv1.Subscription{},
 	}
 )
 
@@ -61,6 +75,7 @@ func newReconciler(mgr manager.Manager) *ReconcileKnativeEventing {
 		client:    client,
 		scheme:    mgr.GetScheme(),
 		mgr:       mgr,
+		recorder:  mgr.GetEventRecorderFor("knativeeventing-controller"),
 		telemetry: t,
 	}
 }
@@ -68,12 +83,60 @@ func newReconciler(mgr manager.Manager) *ReconcileKnativeEventing {
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r *ReconcileKnativeEventing) error {
 	// Create a new controller
-	c, err := controller.New("knativeeventing-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("knativeeventing-controller", mgr, controller.Options{Reconciler: common.ObservedReconciler("eventing", r)})
 	if err != nil {
 		return err
 	}
 	// Watch for changes to primary resource KnativeEventing
-	return c.Watch(&source.Kind{Type: &eventingv1alpha1.KnativeEventing{}}, &handler.EnqueueRequestForObject{})
+	err = c.Watch(&source.Kind{Type: &eventingv1alpha1.KnativeEventing{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the owned monitoring resources, as far as they are served by the cluster
+	for _, t := range []runtime.Object{&monitoringv1.ServiceMonitor{}, &rbacv1.Role{}, &rbacv1.RoleBinding{}} {
+		gvk, err := apiutil.GVKForObject(t, mgr.GetScheme())
+		if err != nil {
+			return err
+		}
+		if _, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			if meta.IsNoMatchError(err) {
+				log.Info("Monitoring resource not served by the cluster, not watching it", "kind", gvk.Kind)
+				continue
+			}
+			return err
+		}
+		err = c.Watch(&source.Kind{Type: t}, &handler.EnqueueRequestForOwner{
+			OwnerType:    &eventingv1alpha1.KnativeEventing{},
+			IsController: true,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Watch for changes to the dashboards
+	err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}},
+		common.EnqueueRequestByOwnerAnnotations(common.EventingOwnerName, common.EventingOwnerNamespace))
+	if err != nil {
+		return err
+	}
+
+	// Watch the dashboards installed for grafana-operator, as far as they are served by the cluster
+	err = dashboard.Watch(c, mgr, common.EnqueueRequestByOwnerAnnotations(common.EventingOwnerName, common.EventingOwnerNamespace))
+	if err != nil {
+		return err
+	}
+
+	// Watch the alerts, as far as they are served by the cluster
+	err = alerts.Watch(c, mgr, common.EnqueueRequestByOwnerAnnotations(common.EventingOwnerName, common.EventingOwnerNamespace))
+	if err != nil {
+		return err
+	}
+
+	// Watch the console resources, as far as they are served by the cluster
+	return console.Watch(console.Path(console.EventingConsolePathEnvVar), c, mgr,
+		common.EnqueueRequestByOwnerAnnotations(common.EventingOwnerName, common.EventingOwnerNamespace))
 }
 
 // blank assignment to verify that ReconcileKnativeEventing implements reconcile.Reconciler
@@ -86,7 +149,12 @@ type ReconcileKnativeEventing struct {
 	client    client.Client
 	mgr       manager.Manager
 	scheme    *runtime.Scheme
+	recorder  record.EventRecorder
 	telemetry *telemetry.Telemetry
+	// trace records the reconciliation, if set.
+	trace *tracing.Reconciliation
+	// drifts reports the drift of the resources applied in the reconciliation.
+	drifts *common.DriftReporter
 }
 
 // Reconcile reads that state of the cluster for a KnativeEventing
@@ -94,6 +162,10 @@ func (r *ReconcileKnativeEventing) Reconcile(request reconcile.Request) (reconci
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling KnativeEventing")
 
+	trace := tracing.StartReconciliation("KnativeEventing", request.NamespacedName)
+	defer trace.End()
+	traced := r.traced(trace)
+
 	// Fetch the KnativeEventing instance
 	original := &eventingv1alpha1.KnativeEventing{}
 	err := r.client.Get(context.TODO(), request.NamespacedName, original)
@@ -105,14 +177,14 @@ func (r *ReconcileKnativeEventing) Reconcile(request reconcile.Request) (reconci
 	}
 
 	if original.GetDeletionTimestamp() != nil {
-		return reconcile.Result{}, r.delete(original)
+		return reconcile.Result{}, traced.delete(original)
 	}
 
 	instance := original.DeepCopy()
-	reconcileErr := r.reconcileKnativeEventing(instance)
+	result, reconcileErr := traced.reconcileKnativeEventing(instance)
 
 	if !equality.Semantic.DeepEqual(original.Status, instance.Status) {
-		if err := r.client.Status().Update(context.TODO(), instance); err != nil {
+		if err := traced.client.Status().Update(context.TODO(), instance); err != nil {
 			return reconcile.Result{}, fmt.Errorf("failed to update status: %w", err)
 		}
 	}
@@ -125,22 +197,47 @@ func (r *ReconcileKnativeEventing) Reconcile(request reconcile.Request) (reconci
 	} else {
 		common.KnativeEventingUpG.Set(0)
 	}
-	return reconcile.Result{}, reconcileErr
+	return result, reconcileErr
 }
 
-func (r *ReconcileKnativeEventing) reconcileKnativeEventing(instance *eventingv1alpha1.KnativeEventing) error {
-	stages := []func(*eventingv1alpha1.KnativeEventing) error{
-		r.configure,
-		r.ensureFinalizers,
-		r.installServiceMonitors,
-		r.installDashboards,
+func (r *ReconcileKnativeEventing) reconcileKnativeEventing(instance *eventingv1alpha1.KnativeEventing) (reconcile.Result, error) {
+	conditions := eventingCondSet.Manage(&instance.Status)
+	if common.ReportReconcilePaused(instance, conditions, common.KnativeEventingPausedG) {
+		return reconcile.Result{}, nil
 	}
-	for _, stage := range stages {
-		if err := stage(instance); err != nil {
-			return err
-		}
+
+	pipeline := common.Pipeline{
+		Component:  "eventing",
+		Conditions: conditions,
+		Trace:      r.trace,
 	}
-	return nil
+	return pipeline.Run(
+		common.Stage{Name: "configure", Run: func() common.StageResult {
+			return common.Error(r.configure(instance))
+		}},
+		common.Stage{Name: "finalizers", Run: func() common.StageResult {
+			return common.Error(common.EnsureFinalizer(r.client, instance, finalizerName))
+		}},
+		common.Stage{Name: "service-monitors", Condition: ServiceMonitorsInstalled, Reason: "InstallFailed", Run: func() common.StageResult {
+			return common.Error(r.installServiceMonitors(instance))
+		}},
+		common.Stage{Name: "dashboards", Condition: DashboardsInstalled, Reason: "InstallFailed", Run: func() common.StageResult {
+			return common.Error(r.installDashboards(instance))
+		}},
+		common.Stage{Name: "alerts", Condition: AlertsInstalled, Reason: "InstallFailed", Run: func() common.StageResult {
+			return common.Error(r.installAlerts(instance))
+		}},
+		common.Stage{Name: "console", Condition: ConsoleResourcesInstalled, Reason: "InstallFailed", Run: func() common.StageResult {
+			return common.Error(r.installConsoleResources(instance))
+		}},
+		common.Stage{Name: "images", Run: func() common.StageResult {
+			return common.Error(r.reportImages(instance))
+		}},
+		common.Stage{Name: "drift", Run: func() common.StageResult {
+			r.drift(instance).MarkResourcesInSync(conditions)
+			return common.Done()
+		}},
+	)
 }
 
 // configure default settings for OpenShift
@@ -159,73 +256,110 @@ func (r *ReconcileKnativeEventing) configure(instance *eventingv1alpha1.KnativeE
 	return nil
 }
 
-// set a finalizer to clean up the dashboard when instance is deleted
-func (r *ReconcileKnativeEventing) ensureFinalizers(instance *eventingv1alpha1.KnativeEventing) error {
-	for _, finalizer := range instance.GetFinalizers() {
-		if finalizer == finalizerName {
-			return nil
-		}
-	}
-	log.Info("Adding finalizer")
-	instance.SetFinalizers(append(instance.GetFinalizers(), finalizerName))
-	return r.client.Update(context.TODO(), instance)
-}
-
 // installServiceMonitors installs service monitors for eventing dashboards
 func (r *ReconcileKnativeEventing) installServiceMonitors(instance *eventingv1alpha1.KnativeEventing) error {
 	log.Info("Installing Eventing Service Monitors")
-	if err := common.SetupMonitoringRequirements(r.client, instance); err != nil {
-		return err
-	}
-	if err := common.SetupEventingBrokerServiceMonitors(r.client, instance); err != nil {
+	api := r.drift(instance).Client(r.client)
+	if err := r.trace.Apply("monitoring-requirements", func() error {
+		return common.SetupMonitoringRequirements(api, instance)
+	}); err != nil {
 		return err
 	}
-	return nil
+	return r.trace.Apply("broker-service-monitors", func() error {
+		return common.SetupEventingBrokerServiceMonitors(api, instance)
+	})
 }
 
 // installDashboard installs dashboard for OpenShift webconsole
 func (r *ReconcileKnativeEventing) installDashboards(instance *eventingv1alpha1.KnativeEventing) error {
 	log.Info("Installing Eventing Dashboards")
-	if err := dashboard.Apply(os.Getenv(dashboard.EventingBrokerDashboardPathEnvVar), instance, r.client); err != nil {
+	api := r.drift(instance).Client(r.client)
+	if err := r.trace.Apply("broker-dashboard", func() error {
+		return dashboard.Apply(os.Getenv(dashboard.EventingBrokerDashboardPathEnvVar), instance, api)
+	}); err != nil {
 		return err
 	}
-	if err := dashboard.Apply(os.Getenv(dashboard.EventingSourceDashboardPathEnvVar), instance, r.client); err != nil {
-		return err
+	return r.trace.Apply("source-dashboard", func() error {
+		return dashboard.Apply(os.Getenv(dashboard.EventingSourceDashboardPathEnvVar), instance, api)
+	})
+}
+
+// installAlerts installs the alerting rules for the cluster monitoring
+func (r *ReconcileKnativeEventing) installAlerts(instance *eventingv1alpha1.KnativeEventing) error {
+	return alerts.Apply(alerts.Path(alerts.EventingAlertsPathEnvVar), instance, eventingOwner(instance), r.drift(instance).Client(r.client))
+}
+
+// installConsoleResources installs YAML samples and quick starts for OpenShift webconsole
+func (r *ReconcileKnativeEventing) installConsoleResources(instance *eventingv1alpha1.KnativeEventing) error {
+	return console.Apply(console.Path(console.EventingConsolePathEnvVar), eventingOwner(instance), r.drift(instance).Client(r.client))
+}
+
+// reportImages reports the images of the Eventing deployments.
+func (r *ReconcileKnativeEventing) reportImages(instance *eventingv1alpha1.KnativeEventing) error {
+	list := &appsv1.DeploymentList{}
+	if err := r.client.List(context.TODO(), list, client.InNamespace(instance.Namespace)); err != nil {
+		return fmt.Errorf("failed to list deployments: %w", err)
 	}
-	return nil
+	// Only the deployments created by the upstream operator for the instance.
+	var deployments []appsv1.Deployment
+	for _, d := range list.Items {
+		if metav1.IsControlledBy(&d, instance) {
+			deployments = append(deployments, d)
+		}
+	}
+	overrides := common.ContainerImageOverrides(common.BuildImageOverrideMapFromEnviron(os.Environ(), "IMAGE_"))
+	return common.ReportImages("eventing", eventingCondSet.Manage(&instance.Status), &instance.Status.Status,
+		common.DeploymentImages(deployments, overrides))
 }
 
 // general clean-up, mostly resources in different namespaces from eventingv1alpha1.KnativeEventing.
 func (r *ReconcileKnativeEventing) delete(instance *eventingv1alpha1.KnativeEventing) error {
 	// Stop telemetry
 	defer r.telemetry.TryStop()
-	finalizers := sets.NewString(instance.GetFinalizers()...)
+	// The resources are deleted, so is their drift.
+	defer common.ForgetDrift("eventing")
 
-	if !finalizers.Has(finalizerName) {
-		log.Info("Finalizer has already been removed, nothing to do")
+	return common.Finalize(r.client, instance, finalizerName, func() error {
+		log.Info("Deleting eventing dashboards")
+		if err := dashboard.Delete(os.Getenv(dashboard.EventingBrokerDashboardPathEnvVar), instance, r.client); err != nil {
+			return fmt.Errorf("failed to delete dashboard broker configmap: %w", err)
+		}
+		if err := dashboard.Delete(os.Getenv(dashboard.EventingSourceDashboardPathEnvVar), instance, r.client); err != nil {
+			return fmt.Errorf("failed to delete dashboard filter configmap: %w", err)
+		}
+		log.Info("Deleting alerts")
+		if err := alerts.Delete(alerts.Path(alerts.EventingAlertsPathEnvVar), eventingOwner(instance), r.client); err != nil {
+			return fmt.Errorf("failed to delete alerts: %w", err)
+		}
+		log.Info("Deleting console resources")
+		if err := console.Delete(console.Path(console.EventingConsolePathEnvVar), eventingOwner(instance), r.client); err != nil {
+			return fmt.Errorf("failed to delete console resources: %w", err)
+		}
 		return nil
-	}
-	log.Info("Running cleanup logic")
-	log.Info("Deleting eventing dashboards")
-	if err := dashboard.Delete(os.Getenv(dashboard.EventingBrokerDashboardPathEnvVar), instance, r.client); err != nil {
-		return fmt.Errorf("failed to delete dashboard broker configmap: %w", err)
-	}
-	if err := dashboard.Delete(os.Getenv(dashboard.EventingSourceDashboardPathEnvVar), instance, r.client); err != nil {
-		return fmt.Errorf("failed to delete dashboard filter configmap: %w", err)
-	}
-	// The above might take a while, so we refetch the resource again in case it has changed.
-	refetched := &eventingv1alpha1.KnativeEventing{}
-	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}, refetched); err != nil {
-		return fmt.Errorf("failed to refetch KnativeEventing: %w", err)
-	}
+	})
+}
 
-	// Update the refetched finalizer list.
-	finalizers = sets.NewString(refetched.GetFinalizers()...)
-	finalizers.Delete(finalizerName)
-	refetched.SetFinalizers(finalizers.List())
+// traced returns a copy of the reconciler recording its stages and API calls in the given trace.
+func (r *ReconcileKnativeEventing) traced(trace *tracing.Reconciliation) *ReconcileKnativeEventing {
+	traced := *r
+	traced.client = trace.Client(r.client)
+	traced.trace = trace
+	traced.drifts = nil
+	return &traced
+}
 
-	if err := r.client.Update(context.TODO(), refetched); err != nil {
-		return fmt.Errorf("failed to update KnativeEventing with removed finalizer: %w", err)
+// drift returns the reporter of drift of the resources applied for the instance. The reporter is shared by
+// the stages of a reconciliation, so that the drift kept by any of them is reflected in the status.
+func (r *ReconcileKnativeEventing) drift(instance *eventingv1alpha1.KnativeEventing) *common.DriftReporter {
+	if r.drifts == nil || r.drifts.Instance != instance {
+		r.drifts = &common.DriftReporter{Component: "eventing", Instance: instance, Recorder: r.recorder}
+	}
+	return r.drifts
+}
+
+func eventingOwner(instance *eventingv1alpha1.KnativeEventing) map[string]string {
+	return map[string]string{
+		common.EventingOwnerName:      instance.Name,
+		common.EventingOwnerNamespace: instance.Namespace,
 	}
-	return nil
 }
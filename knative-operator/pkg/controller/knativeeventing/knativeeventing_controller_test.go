@@ -8,6 +8,8 @@ import (
 	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/alerts"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/console"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/dashboard"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -16,6 +18,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	pkgapis "knative.dev/pkg/apis"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -49,6 +52,8 @@ func init() {
 	os.Setenv(common.TestRolePath, "../dashboard/testdata/role_service_monitor.yaml")
 	os.Setenv(common.TestEventingBrokerServiceMonitorPath, "../dashboard/testdata/broker-service-monitors.yaml")
 	os.Setenv(common.TestMonitor, "true")
+	os.Setenv(console.EventingConsolePathEnvVar, "../../../deploy/resources/console/eventing-console.yaml")
+	os.Setenv(alerts.EventingAlertsPathEnvVar, "../../../deploy/resources/alerts/eventing-alerts.yaml")
 
 	apis.AddToScheme(scheme.Scheme)
 }
@@ -86,6 +91,15 @@ func TestEventingReconcile(t *testing.T) {
 			if _, err := r.Reconcile(req); err != nil {
 				t.Fatalf("reconcile: (%v)", err)
 			}
+			got := &v1alpha1.KnativeEventing{}
+			if err := cl.Get(context.TODO(), req.NamespacedName, got); err != nil {
+				t.Fatalf("get: (%v)", err)
+			}
+			for _, c := range []pkgapis.ConditionType{ServiceMonitorsInstalled, DashboardsInstalled, AlertsInstalled, ConsoleResourcesInstalled} {
+				if cond := got.Status.GetCondition(c); cond == nil || !cond.IsTrue() {
+					t.Errorf("Condition %s = %v, want true", c, cond)
+				}
+			}
 			// Check if Eventing dashboard configmaps are available
 			brokerCM := &corev1.ConfigMap{}
 			err := cl.Get(context.TODO(), types.NamespacedName{Name: "grafana-dashboard-definition-knative-eventing-broker", Namespace: dashboardNamespace.Name}, brokerCM)
@@ -0,0 +1,28 @@
+package knativeeventing
+
+import (
+	eventingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/pkg/apis"
+)
+
+// Conditions reflecting the OpenShift specific stages of the KnativeEventing reconciliation.
+// They are informational and don't affect the Ready condition.
+const (
+	// ServiceMonitorsInstalled reflects the monitoring requirements and the ServiceMonitors of the brokers.
+	ServiceMonitorsInstalled apis.ConditionType = "ServiceMonitorsInstalled"
+	// DashboardsInstalled reflects the installation of the broker and source dashboards in the console.
+	DashboardsInstalled apis.ConditionType = "DashboardsInstalled"
+	// AlertsInstalled reflects the installation of the Eventing alerting rules.
+	AlertsInstalled apis.ConditionType = "AlertsInstalled"
+	// ConsoleResourcesInstalled reflects the YAML samples and quick starts in the console.
+	ConsoleResourcesInstalled apis.ConditionType = "ConsoleResourcesInstalled"
+)
+
+// eventingCondSet mirrors the condition set of the upstream KnativeEventing, so the OpenShift
+// specific conditions are managed as non-dependent conditions of the same happy condition.
+var eventingCondSet = apis.NewLivingConditionSet(
+	eventingv1alpha1.DependenciesInstalled,
+	eventingv1alpha1.DeploymentsAvailable,
+	eventingv1alpha1.InstallSucceeded,
+	eventingv1alpha1.VersionMigrationEligible,
+)
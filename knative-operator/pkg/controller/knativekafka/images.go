@@ -83,7 +83,7 @@ func updateDeployment(imageTransformer ImageTransformer, u *unstructured.Unstruc
 	// superfluous updates
 	u.SetCreationTimestamp(metav1.Time{})
 
-	log.Info("Finished conversion", "name", u.GetName(), "unstructured", u.Object)
+	log.V(1).Info("Finished conversion", "name", u.GetName(), "unstructured", u.Object)
 	return nil
 }
 
@@ -101,7 +101,7 @@ func updateDaemonSet(imageTransformer ImageTransformer, u *unstructured.Unstruct
 	// superfluous updates
 	u.SetCreationTimestamp(metav1.Time{})
 
-	log.Info("Finished conversion", "name", u.GetName(), "unstructured", u.Object)
+	log.V(1).Info("Finished conversion", "name", u.GetName(), "unstructured", u.Object)
 	return nil
 }
 
@@ -119,12 +119,12 @@ func updateJob(imageTransformer ImageTransformer, u *unstructured.Unstructured,
 	// superfluous updates
 	u.SetCreationTimestamp(metav1.Time{})
 
-	log.Info("Finished conversion", "name", u.GetName(), "unstructured", u.Object)
+	log.V(1).Info("Finished conversion", "name", u.GetName(), "unstructured", u.Object)
 	return nil
 }
 
 func updateRegistry(spec *corev1.PodSpec, imageTransformer ImageTransformer, log logr.Logger, name string) {
-	log.Info("Updating", "name", name, "imageTransformer", imageTransformer)
+	log.V(1).Info("Updating", "name", name, "imageTransformer", imageTransformer)
 
 	updateImage(spec, imageTransformer, log, name)
 	updateEnvVarImages(spec, imageTransformer)
@@ -140,7 +140,7 @@ func updateImage(spec *corev1.PodSpec, imageTransformer ImageTransformer, log lo
 			updateContainer(container, newImage, log)
 		}
 	}
-	log.Info("Finished updating images", "name", name, "containers", spec.Containers)
+	log.V(1).Info("Finished updating images", "name", name, "containers", spec.Containers)
 }
 
 func updateEnvVarImages(spec *corev1.PodSpec, imageTransformer ImageTransformer) {
@@ -157,6 +157,6 @@ func updateEnvVarImages(spec *corev1.PodSpec, imageTransformer ImageTransformer)
 }
 
 func updateContainer(container *corev1.Container, newImage string, log logr.Logger) {
-	log.Info("Updating container image from: %v, to: %v", container.Image, newImage)
+	log.Info("Updating container image", "from", container.Image, "to", newImage)
 	container.Image = newImage
 }
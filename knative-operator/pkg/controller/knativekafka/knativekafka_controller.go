@@ -10,6 +10,10 @@ import (
 	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common/telemetry"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common/tracing"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/alerts"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/console"
+	kafkachannelv1beta1 "knative.dev/eventing-contrib/kafka/channel/pkg/apis/messaging/v1beta1"
 	kafkasourcev1beta1 "knative.dev/eventing-contrib/kafka/source/pkg/apis/sources/v1beta1"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -18,13 +22,12 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"knative.dev/pkg/apis"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
-	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
@@ -37,13 +40,10 @@ const (
 )
 
 var (
-	log                 = logf.Log.WithName("controller_knativekafka")
-	role                = mf.Any(mf.ByKind("ClusterRole"), mf.ByKind("Role"))
-	rolebinding         = mf.Any(mf.ByKind("ClusterRoleBinding"), mf.ByKind("RoleBinding"))
-	roleOrRoleBinding   = mf.Any(role, rolebinding)
-	knativeKafkaObjects = []runtime.Object{
-		&kafkasourcev1beta1.KafkaSource{},
-	}
+	log               = common.Log.WithName("controller").WithName("knativekafka")
+	role              = mf.Any(mf.ByKind("ClusterRole"), mf.ByKind("Role"))
+	rolebinding       = mf.Any(mf.ByKind("ClusterRoleBinding"), mf.ByKind("RoleBinding"))
+	roleOrRoleBinding = mf.Any(role, rolebinding)
 )
 
 type stage func(*mf.Manifest, *operatorv1alpha1.KnativeKafka) error
@@ -60,17 +60,13 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) (*ReconcileKnativeKafka, error) {
-	kafkaChannelManifest, err := mf.ManifestFrom(mf.Path(os.Getenv("KAFKACHANNEL_MANIFEST_PATH")))
-	if err != nil {
-		return nil, fmt.Errorf("failed to load KafkaChannel manifest: %w", err)
-	}
-
-	kafkaSourceManifest, err := mf.ManifestFrom(mf.Path(os.Getenv("KAFKASOURCE_MANIFEST_PATH")))
+	kafkaChannelManifest, kafkaSourceManifest, err := rawManifests()
 	if err != nil {
-		return nil, fmt.Errorf("failed to load KafkaSource manifest: %w", err)
+		return nil, err
 	}
 
-	t, err := telemetry.NewTelemetry("knativeKafka", mgr, knativeKafkaObjects, mgr.GetClient())
+	// The objects are set per reconciliation, as they depend on the enabled components.
+	t, err := telemetry.NewTelemetry("knativeKafka", mgr, nil, mgr.GetClient())
 	if err != nil {
 		log.Error(err, "failed to create telemetry for knativeKafka")
 	}
@@ -80,15 +76,52 @@ func newReconciler(mgr manager.Manager) (*ReconcileKnativeKafka, error) {
 		scheme:                  mgr.GetScheme(),
 		rawKafkaChannelManifest: kafkaChannelManifest,
 		rawKafkaSourceManifest:  kafkaSourceManifest,
+		recorder:                mgr.GetEventRecorderFor("knativekafka-controller"),
 		telemetry:               t,
 	}
 	return &reconcileKnativeKafka, nil
 }
 
+// rawManifests loads the KafkaChannel and KafkaSource manifests.
+func rawManifests() (mf.Manifest, mf.Manifest, error) {
+	kafkaChannelManifest, err := mf.ManifestFrom(mf.Path(os.Getenv("KAFKACHANNEL_MANIFEST_PATH")))
+	if err != nil {
+		return mf.Manifest{}, mf.Manifest{}, fmt.Errorf("failed to load KafkaChannel manifest: %w", err)
+	}
+
+	kafkaSourceManifest, err := mf.ManifestFrom(mf.Path(os.Getenv("KAFKASOURCE_MANIFEST_PATH")))
+	if err != nil {
+		return mf.Manifest{}, mf.Manifest{}, fmt.Errorf("failed to load KafkaSource manifest: %w", err)
+	}
+	return kafkaChannelManifest, kafkaSourceManifest, nil
+}
+
+// Manifest returns the manifest of the components enabled by the instance, transformed the
+// same way as when they are applied.
+func Manifest(instance *operatorv1alpha1.KnativeKafka, api client.Client) (*mf.Manifest, error) {
+	kafkaChannelManifest, kafkaSourceManifest, err := rawManifests()
+	if err != nil {
+		return nil, err
+	}
+	r := &ReconcileKnativeKafka{
+		client:                  api,
+		rawKafkaChannelManifest: kafkaChannelManifest,
+		rawKafkaSourceManifest:  kafkaSourceManifest,
+	}
+	manifest, err := r.buildManifest(instance, manifestBuildEnabledOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load and build manifest: %w", err)
+	}
+	if err := r.transform(manifest, instance); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r *ReconcileKnativeKafka) error {
 	// Create a new controller
-	c, err := controller.New("knativekafka-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("knativekafka-controller", mgr, controller.Options{Reconciler: common.ObservedReconciler("kafka", r)})
 	if err != nil {
 		return err
 	}
@@ -107,7 +140,16 @@ func add(mgr manager.Manager, r *ReconcileKnativeKafka) error {
 			return err
 		}
 	}
-	return nil
+
+	// Watch the alerts, as far as they are served by the cluster
+	err = alerts.Watch(c, mgr, common.EnqueueRequestByOwnerAnnotations(common.KafkaOwnerName, common.KafkaOwnerNamespace))
+	if err != nil {
+		return err
+	}
+
+	// Watch the console resources, as far as they are served by the cluster
+	return console.Watch(console.Path(console.KafkaConsolePathEnvVar), c, mgr,
+		common.EnqueueRequestByOwnerAnnotations(common.KafkaOwnerName, common.KafkaOwnerNamespace))
 }
 
 // blank assignment to verify that ReconcileKnativeKafka implements reconcile.Reconciler
@@ -122,7 +164,12 @@ type ReconcileKnativeKafka struct {
 	scheme                  *runtime.Scheme
 	rawKafkaChannelManifest mf.Manifest
 	rawKafkaSourceManifest  mf.Manifest
+	recorder                record.EventRecorder
 	telemetry               *telemetry.Telemetry
+	// trace records the reconciliation, if set.
+	trace *tracing.Reconciliation
+	// drifts reports the drift of the resources applied in the reconciliation.
+	drifts *common.DriftReporter
 }
 
 // Reconcile reads that state of the cluster for a KnativeKafka object and makes changes based on the state read
@@ -131,6 +178,10 @@ func (r *ReconcileKnativeKafka) Reconcile(request reconcile.Request) (reconcile.
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling KnativeKafka")
 
+	trace := tracing.StartReconciliation("KnativeKafka", request.NamespacedName)
+	defer trace.End()
+	traced := r.traced(trace)
+
 	// Fetch the KnativeKafka instance
 	original := &operatorv1alpha1.KnativeKafka{}
 	err := r.client.Get(context.TODO(), request.NamespacedName, original)
@@ -147,93 +198,103 @@ func (r *ReconcileKnativeKafka) Reconcile(request reconcile.Request) (reconcile.
 
 	// check for deletion
 	if original.GetDeletionTimestamp() != nil {
-		return reconcile.Result{}, r.delete(original)
+		return reconcile.Result{}, traced.delete(original)
 	}
 
 	instance := original.DeepCopy()
-	reconcileErr := r.reconcileKnativeKafka(instance)
+	result, reconcileErr := traced.reconcileKnativeKafka(instance)
 
 	if !equality.Semantic.DeepEqual(original.Status, instance.Status) {
-		if err := r.client.Status().Update(context.TODO(), instance); err != nil {
+		if err := traced.client.Status().Update(context.TODO(), instance); err != nil {
 			return reconcile.Result{}, fmt.Errorf("failed to update status: %w", err)
 		}
 	}
 
 	if instance.Status.IsReady() {
 		common.KnativeKafkaUpG.Set(1)
+		r.telemetry.SetObjects(telemetryObjects(instance))
 		if err := r.telemetry.TryStart(r.client, r.mgr); err != nil {
 			return reconcile.Result{}, err
 		}
 	} else {
 		common.KnativeKafkaUpG.Set(0)
 	}
-	return reconcile.Result{}, reconcileErr
+	return result, reconcileErr
 }
 
-func (r *ReconcileKnativeKafka) reconcileKnativeKafka(instance *operatorv1alpha1.KnativeKafka) error {
+func (r *ReconcileKnativeKafka) reconcileKnativeKafka(instance *operatorv1alpha1.KnativeKafka) (reconcile.Result, error) {
 	instance.Status.InitializeConditions()
-
-	// install the components that are enabled
-	if err := r.executeInstallStages(instance); err != nil {
-		return err
-	}
-	// delete the components that are disabled
-	if err := r.executeDeleteStages(instance); err != nil {
-		return err
+	if common.ReportReconcilePaused(instance, instance.Status.GetConditionSet().Manage(&instance.Status), common.KnativeKafkaPausedG) {
+		return reconcile.Result{}, nil
 	}
-	return nil
-}
 
-func (r *ReconcileKnativeKafka) executeInstallStages(instance *operatorv1alpha1.KnativeKafka) error {
-	manifest, err := r.buildManifest(instance, manifestBuildEnabledOnly)
+	enabled, err := r.buildManifest(instance, manifestBuildEnabledOnly)
 	if err != nil {
-		return fmt.Errorf("failed to load and build manifest: %w", err)
+		return reconcile.Result{}, fmt.Errorf("failed to load and build manifest: %w", err)
 	}
-
-	stages := []stage{
-		r.ensureFinalizers,
-		r.transform,
-		r.apply,
-		r.checkDeployments,
-	}
-
-	return executeStages(instance, manifest, stages)
+	disabled, err := r.buildManifest(instance, manifestBuildDisabledOnly)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to load and build manifest: %w", err)
+	}
+
+	pipeline := common.Pipeline{
+		Component:  "kafka",
+		Conditions: instance.Status.GetConditionSet().Manage(&instance.Status),
+		Trace:      r.trace,
+	}
+	return pipeline.Run(
+		common.Stage{Name: "finalizers", Run: func() common.StageResult {
+			return common.Error(common.EnsureFinalizer(r.client, instance, finalizerName))
+		}},
+		// install the components that are enabled
+		r.manifestStage("transform", r.transform, enabled, instance),
+		r.manifestStage("apply", r.apply, enabled, instance),
+		withCondition(r.manifestStage("console", r.installConsoleResources, enabled, instance), ConsoleResourcesInstalled, "InstallFailed"),
+		withCondition(r.manifestStage("alerts", r.installAlerts, enabled, instance), AlertsInstalled, "InstallFailed"),
+		r.manifestStage("check-deployments", r.checkDeployments, enabled, instance),
+		r.manifestStage("images", r.reportImages, enabled, instance),
+		// delete the components that are disabled
+		r.manifestStage("transform-disabled", r.transform, disabled, instance),
+		withCondition(r.manifestStage("delete-disabled", r.deleteResources, disabled, instance), DisabledComponentsRemoved, "DeleteFailed"),
+		common.Stage{Name: "drift", Run: func() common.StageResult {
+			r.drift(instance).MarkResourcesInSync(instance.Status.GetConditionSet().Manage(&instance.Status))
+			return common.Done()
+		}},
+	)
 }
 
-func (r *ReconcileKnativeKafka) executeDeleteStages(instance *operatorv1alpha1.KnativeKafka) error {
-	manifest, err := r.buildManifest(instance, manifestBuildDisabledOnly)
-	if err != nil {
-		return fmt.Errorf("failed to load and build manifest: %w", err)
+// telemetryObjects returns the objects counted by the telemetry, those of the enabled components.
+// The kinds of disabled components might not be served by the cluster.
+func telemetryObjects(instance *operatorv1alpha1.KnativeKafka) []runtime.Object {
+	var objects []runtime.Object
+	if instance.Spec.Source.Enabled {
+		objects = append(objects, &kafkasourcev1beta1.KafkaSource{})
 	}
-
-	stages := []stage{
-		r.transform,
-		r.deleteResources,
+	if instance.Spec.Channel.Enabled {
+		objects = append(objects, &kafkachannelv1beta1.KafkaChannel{})
 	}
+	return objects
+}
 
-	return executeStages(instance, manifest, stages)
+// manifestStage turns a stage operating on the given manifest into a pipeline stage.
+func (r *ReconcileKnativeKafka) manifestStage(name string, s stage, manifest *mf.Manifest, instance *operatorv1alpha1.KnativeKafka) common.Stage {
+	return common.Stage{Name: name, Run: func() common.StageResult {
+		return common.Error(s(manifest, instance))
+	}}
 }
 
-// set a finalizer to clean up cluster-scoped resources and resources from other namespaces
-func (r *ReconcileKnativeKafka) ensureFinalizers(_ *mf.Manifest, instance *operatorv1alpha1.KnativeKafka) error {
-	for _, finalizer := range instance.GetFinalizers() {
-		if finalizer == finalizerName {
-			return nil
-		}
-	}
-	log.Info("Adding finalizer")
-	instance.SetFinalizers(append(instance.GetFinalizers(), finalizerName))
-	return r.client.Update(context.TODO(), instance)
+// withCondition reflects the outcome of the stage in the given condition.
+func withCondition(stage common.Stage, condition apis.ConditionType, reason string) common.Stage {
+	stage.Condition = condition
+	stage.Reason = reason
+	return stage
 }
 
 func (r *ReconcileKnativeKafka) transform(manifest *mf.Manifest, instance *operatorv1alpha1.KnativeKafka) error {
 	log.Info("Transforming manifest")
 	m, err := manifest.Transform(
 		mf.InjectOwner(instance),
-		common.SetAnnotations(map[string]string{
-			common.KafkaOwnerName:      instance.Name,
-			common.KafkaOwnerNamespace: instance.Namespace,
-		}),
+		common.SetAnnotations(kafkaOwner(instance)),
 		setBootstrapServers(instance.Spec.Channel.BootstrapServers),
 		ImageTransform(common.BuildImageOverrideMapFromEnviron(os.Environ(), "KAFKA_IMAGE_"), log),
 	)
@@ -247,18 +308,22 @@ func (r *ReconcileKnativeKafka) transform(manifest *mf.Manifest, instance *opera
 // Install Knative Kafka components
 func (r *ReconcileKnativeKafka) apply(manifest *mf.Manifest, instance *operatorv1alpha1.KnativeKafka) error {
 	log.Info("Installing manifest")
+	// Detect drift of the resources while applying them.
+	applied := *manifest
+	applied.Client = mfc.NewClient(r.drift(instance).Client(r.client))
+	manifest = &applied
 	// The Operator needs a higher level of permissions if it 'bind's non-existent roles.
 	// To avoid this, we strictly order the manifest application as (Cluster)Roles, then
 	// (Cluster)RoleBindings, then the rest of the manifest.
-	if err := manifest.Filter(role).Apply(); err != nil {
+	if err := r.trace.Apply("roles", func() error { return manifest.Filter(role).Apply() }); err != nil {
 		instance.Status.MarkInstallFailed(err.Error())
 		return fmt.Errorf("failed to apply (cluster)roles in manifest: %w", err)
 	}
-	if err := manifest.Filter(rolebinding).Apply(); err != nil {
+	if err := r.trace.Apply("rolebindings", func() error { return manifest.Filter(rolebinding).Apply() }); err != nil {
 		instance.Status.MarkInstallFailed(err.Error())
 		return fmt.Errorf("failed to apply (cluster)rolebindings in manifest: %w", err)
 	}
-	if err := manifest.Filter(not(roleOrRoleBinding)).Apply(); err != nil {
+	if err := r.trace.Apply("resources", func() error { return manifest.Filter(not(roleOrRoleBinding)).Apply() }); err != nil {
 		instance.Status.MarkInstallFailed(err.Error())
 		return fmt.Errorf("failed to apply non rbac manifest: %w", err)
 	}
@@ -266,6 +331,21 @@ func (r *ReconcileKnativeKafka) apply(manifest *mf.Manifest, instance *operatorv
 	return nil
 }
 
+// installConsoleResources installs the KafkaSource YAML samples for OpenShift webconsole
+// if the source is enabled and removes them otherwise.
+func (r *ReconcileKnativeKafka) installConsoleResources(_ *mf.Manifest, instance *operatorv1alpha1.KnativeKafka) error {
+	path := console.Path(console.KafkaConsolePathEnvVar)
+	if instance.Spec.Source.Enabled {
+		return console.Apply(path, kafkaOwner(instance), r.drift(instance).Client(r.client))
+	}
+	return console.Delete(path, kafkaOwner(instance), r.client)
+}
+
+// installAlerts installs the alerting rules for the cluster monitoring
+func (r *ReconcileKnativeKafka) installAlerts(_ *mf.Manifest, instance *operatorv1alpha1.KnativeKafka) error {
+	return alerts.Apply(alerts.Path(alerts.KafkaAlertsPathEnvVar), instance, kafkaOwner(instance), r.drift(instance).Client(r.client))
+}
+
 func (r *ReconcileKnativeKafka) checkDeployments(manifest *mf.Manifest, instance *operatorv1alpha1.KnativeKafka) error {
 	log.Info("Checking deployments")
 	for _, u := range manifest.Filter(mf.ByKind("Deployment")).Resources() {
@@ -291,6 +371,16 @@ func (r *ReconcileKnativeKafka) checkDeployments(manifest *mf.Manifest, instance
 	return nil
 }
 
+// reportImages reports the images of the workloads of the enabled components.
+func (r *ReconcileKnativeKafka) reportImages(manifest *mf.Manifest, instance *operatorv1alpha1.KnativeKafka) error {
+	overrides := common.ContainerImageOverrides(common.BuildImageOverrideMapFromEnviron(os.Environ(), "KAFKA_IMAGE_"))
+	images, err := common.WorkloadImages(r.client, manifest.Resources(), overrides)
+	if err != nil {
+		return fmt.Errorf("failed to determine images: %w", err)
+	}
+	return common.ReportImages("kafka", instance.Status.GetConditionSet().Manage(&instance.Status), &instance.Status.Status, images)
+}
+
 // Delete Knative Kafka resources
 func (r *ReconcileKnativeKafka) deleteResources(manifest *mf.Manifest, instance *operatorv1alpha1.KnativeKafka) error {
 	if len(manifest.Resources()) <= 0 {
@@ -318,34 +408,15 @@ func isDeploymentAvailable(d *appsv1.Deployment) bool {
 
 // general clean-up. required for the resources that cannot be garbage collected with the owner reference mechanism
 func (r *ReconcileKnativeKafka) delete(instance *operatorv1alpha1.KnativeKafka) error {
-	finalizers := sets.NewString(instance.GetFinalizers()...)
-
-	if !finalizers.Has(finalizerName) {
-		log.Info("Finalizer has already been removed, nothing to do")
+	// The resources are deleted, so is their drift.
+	defer common.ForgetDrift("kafka")
+	return common.Finalize(r.client, instance, finalizerName, func() error {
+		log.Info("Deleting KnativeKafka")
+		if err := r.deleteKnativeKafka(instance); err != nil {
+			return fmt.Errorf("failed to delete KnativeKafka: %w", err)
+		}
 		return nil
-	}
-
-	log.Info("Running cleanup logic")
-	log.Info("Deleting KnativeKafka")
-	if err := r.deleteKnativeKafka(instance); err != nil {
-		return fmt.Errorf("failed to delete KnativeKafka: %w", err)
-	}
-
-	// The above might take a while, so we refetch the resource again in case it has changed.
-	refetched := &operatorv1alpha1.KnativeKafka{}
-	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}, refetched); err != nil {
-		return fmt.Errorf("failed to refetch KnativeKafka: %w", err)
-	}
-
-	// Update the refetched finalizer list.
-	finalizers = sets.NewString(refetched.GetFinalizers()...)
-	finalizers.Delete(finalizerName)
-	refetched.SetFinalizers(finalizers.List())
-
-	if err := r.client.Update(context.TODO(), refetched); err != nil {
-		return fmt.Errorf("failed to update KnativeKafka with removed finalizer: %w", err)
-	}
-	return nil
+	})
 }
 
 func (r *ReconcileKnativeKafka) deleteKnativeKafka(instance *operatorv1alpha1.KnativeKafka) error {
@@ -356,11 +427,49 @@ func (r *ReconcileKnativeKafka) deleteKnativeKafka(instance *operatorv1alpha1.Kn
 		return fmt.Errorf("failed to build manifest: %w", err)
 	}
 
-	stages := []stage{
-		r.transform,
-		r.deleteResources,
+	pipeline := common.Pipeline{Component: "kafka", Trace: r.trace}
+	_, err = pipeline.Run(
+		r.manifestStage("delete-transform", r.transform, manifest, instance),
+		r.manifestStage("delete-resources", r.deleteResources, manifest, instance),
+		r.manifestStage("delete-console", r.deleteConsoleResources, manifest, instance),
+		r.manifestStage("delete-alerts", r.deleteAlerts, manifest, instance),
+	)
+	return err
+}
+
+func (r *ReconcileKnativeKafka) deleteConsoleResources(_ *mf.Manifest, instance *operatorv1alpha1.KnativeKafka) error {
+	log.Info("Deleting console resources")
+	return console.Delete(console.Path(console.KafkaConsolePathEnvVar), kafkaOwner(instance), r.client)
+}
+
+func (r *ReconcileKnativeKafka) deleteAlerts(_ *mf.Manifest, instance *operatorv1alpha1.KnativeKafka) error {
+	log.Info("Deleting alerts")
+	return alerts.Delete(alerts.Path(alerts.KafkaAlertsPathEnvVar), kafkaOwner(instance), r.client)
+}
+
+// traced returns a copy of the reconciler recording its stages and API calls in the given trace.
+func (r *ReconcileKnativeKafka) traced(trace *tracing.Reconciliation) *ReconcileKnativeKafka {
+	traced := *r
+	traced.client = trace.Client(r.client)
+	traced.trace = trace
+	traced.drifts = nil
+	return &traced
+}
+
+// drift returns the reporter of drift of the resources applied for the instance. The reporter is shared by
+// the stages of a reconciliation, so that the drift kept by any of them is reflected in the status.
+func (r *ReconcileKnativeKafka) drift(instance *operatorv1alpha1.KnativeKafka) *common.DriftReporter {
+	if r.drifts == nil || r.drifts.Instance != instance {
+		r.drifts = &common.DriftReporter{Component: "kafka", Instance: instance, Recorder: r.recorder}
+	}
+	return r.drifts
+}
+
+func kafkaOwner(instance *operatorv1alpha1.KnativeKafka) map[string]string {
+	return map[string]string{
+		common.KafkaOwnerName:      instance.Name,
+		common.KafkaOwnerNamespace: instance.Namespace,
 	}
-	return executeStages(instance, manifest, stages)
 }
 
 type manifestBuild int
@@ -405,16 +514,6 @@ func setBootstrapServers(bootstrapServers string) mf.Transformer {
 	}
 }
 
-func executeStages(instance *operatorv1alpha1.KnativeKafka, manifest *mf.Manifest, stages []stage) error {
-	// Execute each stage in sequence until one returns an error
-	for _, stage := range stages {
-		if err := stage(manifest, instance); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
 // TODO: get rid of this when we update to Manifestival version that has this function
 var not = func(pred mf.Predicate) mf.Predicate {
 	return func(u *unstructured.Unstructured) bool {
@@ -2,6 +2,8 @@ package knativekafka
 
 import (
 	"context"
+	"fmt"
+	"os"
 	"testing"
 	"time"
 
@@ -9,6 +11,8 @@ import (
 	mf "github.com/manifestival/manifestival"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/alerts"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/console"
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -16,6 +20,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	pkgapis "knative.dev/pkg/apis"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -27,6 +32,8 @@ var (
 )
 
 func init() {
+	os.Setenv(console.KafkaConsolePathEnvVar, "../../../deploy/resources/console/kafka-console.yaml")
+	os.Setenv(alerts.KafkaAlertsPathEnvVar, "../../../deploy/resources/alerts/kafka-alerts.yaml")
 	apis.AddToScheme(scheme.Scheme)
 }
 
@@ -106,6 +113,17 @@ func TestKnativeKafkaReconcile(t *testing.T) {
 			if _, err := r.Reconcile(defaultRequest); err != nil {
 				t.Fatalf("reconcile: (%v)", err)
 			}
+			if test.instance.DeletionTimestamp == nil {
+				got := &v1alpha1.KnativeKafka{}
+				if err := cl.Get(context.TODO(), defaultRequest.NamespacedName, got); err != nil {
+					t.Fatalf("get: (%v)", err)
+				}
+				for _, c := range []pkgapis.ConditionType{ConsoleResourcesInstalled, AlertsInstalled} {
+					if cond := got.Status.GetCondition(c); cond == nil || !cond.IsTrue() {
+						t.Errorf("Condition %s = %v, want true", c, cond)
+					}
+				}
+			}
 
 			// check if things that should exist is created
 			for _, d := range test.exists {
@@ -305,3 +323,40 @@ func withDeleted(kk *v1alpha1.KnativeKafka) {
 	t := metav1.NewTime(time.Now())
 	kk.ObjectMeta.DeletionTimestamp = &t
 }
+
+func TestTelemetryObjects(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    v1alpha1.KnativeKafkaSpec
+		expects []string
+	}{{
+		name: "nothing enabled",
+	}, {
+		name:    "channel enabled",
+		spec:    v1alpha1.KnativeKafkaSpec{Channel: v1alpha1.Channel{Enabled: true}},
+		expects: []string{"*v1beta1.KafkaChannel"},
+	}, {
+		name:    "source enabled",
+		spec:    v1alpha1.KnativeKafkaSpec{Source: v1alpha1.Source{Enabled: true}},
+		expects: []string{"*v1beta1.KafkaSource"},
+	}, {
+		name: "both enabled",
+		spec: v1alpha1.KnativeKafkaSpec{
+			Channel: v1alpha1.Channel{Enabled: true},
+			Source:  v1alpha1.Source{Enabled: true},
+		},
+		expects: []string{"*v1beta1.KafkaSource", "*v1beta1.KafkaChannel"},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, obj := range telemetryObjects(&v1alpha1.KnativeKafka{Spec: test.spec}) {
+				got = append(got, fmt.Sprintf("%T", obj))
+			}
+			if !cmp.Equal(test.expects, got) {
+				t.Errorf("telemetryObjects() (-want, +got) = %s", cmp.Diff(test.expects, got))
+			}
+		})
+	}
+}
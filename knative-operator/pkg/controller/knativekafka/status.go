@@ -0,0 +1,17 @@
+package knativekafka
+
+import (
+	"knative.dev/pkg/apis"
+)
+
+// Conditions reflecting the OpenShift specific stages of the KnativeKafka reconciliation.
+// They are informational and don't affect the Ready condition. The installation of the
+// components is reflected in the InstallSucceeded and DeploymentsAvailable conditions.
+const (
+	// ConsoleResourcesInstalled reflects the YAML samples and quick starts in the console.
+	ConsoleResourcesInstalled apis.ConditionType = "ConsoleResourcesInstalled"
+	// AlertsInstalled reflects the installation of the Kafka alerting rules.
+	AlertsInstalled apis.ConditionType = "AlertsInstalled"
+	// DisabledComponentsRemoved reflects the deletion of the resources of disabled components.
+	DisabledComponentsRemoved apis.ConditionType = "DisabledComponentsRemoved"
+)
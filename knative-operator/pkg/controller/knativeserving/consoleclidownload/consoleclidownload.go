@@ -2,6 +2,7 @@ package consoleclidownload
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -85,15 +86,29 @@ func reconcileKnCCDResources(instance *servingv1alpha1.KnativeServing, apiclient
 func reconcileKnConsoleCLIDownload(apiclient client.Client, instance *servingv1alpha1.KnativeServing, knService *servingv1.Service) error {
 
 	log.Info("Installing kn ConsoleCLIDownload")
-	ctx := context.TODO()
 
 	knRouteURL := knService.Status.URL
 	if knRouteURL == nil || knRouteURL.String() == "" {
 		return fmt.Errorf("failed to get kn ConsoleCLIDownload Knative Service URL")
 	}
 
+	baseURL := https(knRouteURL.Host)
+	knLinks, linksErr := downloadLinks(instance, knService, baseURL)
+	// The default links are installed until the index is available.
+	if linksErr != nil && !errors.Is(linksErr, ErrIndexUnavailable) {
+		return linksErr
+	}
+	if err := applyKnConsoleCLIDownload(apiclient, instance, knLinks); err != nil {
+		return err
+	}
+	return linksErr
+}
+
+// applyKnConsoleCLIDownload creates or updates the kn ConsoleCLIDownload with the given links.
+func applyKnConsoleCLIDownload(apiclient client.Client, instance *servingv1alpha1.KnativeServing, knLinks []consolev1.CLIDownloadLink) error {
+	ctx := context.TODO()
 	knCCDGet := &consolev1.ConsoleCLIDownload{}
-	knConsoleObj := populateKnConsoleCLIDownload(https(knRouteURL.Host), instance)
+	knConsoleObj := populateKnConsoleCLIDownload(knLinks, instance)
 
 	// Check if kn ConsoleCLIDownload exists
 	err := apiclient.Get(ctx, client.ObjectKey{Namespace: "", Name: knCLIDownload}, knCCDGet)
@@ -130,7 +145,7 @@ func reconcileKnConsoleCLIDownload(apiclient client.Client, instance *servingv1a
 // Delete deletes kn ConsoleCLIDownload CO and respective deployment resources
 func Delete(instance *servingv1alpha1.KnativeServing, apiclient client.Client, scheme *runtime.Scheme) error {
 	log.Info("Deleting kn ConsoleCLIDownload CO")
-	if err := apiclient.Delete(context.TODO(), populateKnConsoleCLIDownload("", nil)); err != nil && !apierrors.IsNotFound(err) {
+	if err := apiclient.Delete(context.TODO(), populateKnConsoleCLIDownload(nil, nil)); err != nil && !apierrors.IsNotFound(err) {
 		return fmt.Errorf("failed to delete kn ConsoleCLIDownload CO: %w", err)
 	}
 
@@ -204,8 +219,8 @@ func makeKnService(image string, instance *servingv1alpha1.KnativeServing) *serv
 }
 
 // populateKnConsoleCLIDownload populates kn ConsoleCLIDownload object and its SPEC
-// using route's baseURL
-func populateKnConsoleCLIDownload(baseURL string, instance *servingv1alpha1.KnativeServing) *consolev1.ConsoleCLIDownload {
+// using the given download links
+func populateKnConsoleCLIDownload(links []consolev1.CLIDownloadLink, instance *servingv1alpha1.KnativeServing) *consolev1.ConsoleCLIDownload {
 	anno := make(map[string]string)
 	if instance != nil {
 		anno = map[string]string{
@@ -221,20 +236,7 @@ func populateKnConsoleCLIDownload(baseURL string, instance *servingv1alpha1.Knat
 		Spec: consolev1.ConsoleCLIDownloadSpec{
 			DisplayName: "kn - OpenShift Serverless Command Line Interface (CLI)",
 			Description: "The OpenShift Serverless client `kn` is a CLI tool that allows you to fully manage OpenShift Serverless Serving and Eventing resources without writing a single line of YAML.",
-			Links: []consolev1.CLIDownloadLink{
-				{
-					Text: "Download kn for Linux",
-					Href: baseURL + "/amd64/linux/kn-linux-amd64.tar.gz",
-				},
-				{
-					Text: "Download kn for macOS",
-					Href: baseURL + "/amd64/macos/kn-macos-amd64.tar.gz",
-				},
-				{
-					Text: "Download kn for Windows",
-					Href: baseURL + "/amd64/windows/kn-windows-amd64.zip",
-				},
-			},
+			Links:       links,
 		},
 	}
 }
@@ -0,0 +1,206 @@
+package consoleclidownload
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	consolev1 "github.com/openshift/api/console/v1"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+const (
+	// LinksAnnotation allows to override the links of the kn ConsoleCLIDownload from
+	// the KnativeServing instance. The value is a JSON list of {"text": "...", "href": "..."}
+	// objects. Relative hrefs are resolved against the URL of the kn-cli Knative Service.
+	LinksAnnotation = "serverless.openshift.io/kn-cli-download-links"
+
+	// indexPath is the path of the index file served by the kn-cli Knative Service,
+	// listing all the artifacts available for download.
+	indexPath = "/index.json"
+
+	// FailedIndexTTL is how long the default artifacts are used after the index failed to be
+	// fetched, before fetching it again. The index might not be served yet by a new revision.
+	FailedIndexTTL = time.Minute
+)
+
+// artifactIndex is the content of the index file served by the kn-cli artifacts image.
+type artifactIndex struct {
+	Artifacts []artifact `json:"artifacts"`
+	// Checksums is the path of a file containing the checksums of all artifacts.
+	// +optional
+	Checksums string `json:"checksums,omitempty"`
+}
+
+// artifact describes a single downloadable kn binary.
+type artifact struct {
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+	Path string `json:"path"`
+}
+
+var (
+	// ErrInvalidLinks is returned if the links set by the LinksAnnotation can't be used.
+	ErrInvalidLinks = errors.New("invalid kn ConsoleCLIDownload links")
+	// ErrIndexUnavailable is returned along with the links of the default artifacts if the index
+	// of the kn-cli Knative Service failed to be fetched. It's fetched again after FailedIndexTTL.
+	ErrIndexUnavailable = errors.New("kn artifact index unavailable")
+)
+
+var (
+	// defaultArtifacts are used if the kn-cli Knative Service doesn't serve an index file,
+	// which is the case for artifact images of previous versions.
+	defaultArtifacts = artifactIndex{
+		Artifacts: []artifact{
+			{OS: "linux", Arch: "amd64", Path: "amd64/linux/kn-linux-amd64.tar.gz"},
+			{OS: "macos", Arch: "amd64", Path: "amd64/macos/kn-macos-amd64.tar.gz"},
+			{OS: "windows", Arch: "amd64", Path: "amd64/windows/kn-windows-amd64.zip"},
+		},
+	}
+
+	osNames = map[string]string{
+		"linux":   "Linux",
+		"macos":   "macOS",
+		"darwin":  "macOS",
+		"windows": "Windows",
+	}
+	archNames = map[string]string{
+		"amd64":   "x86_64",
+		"arm64":   "ARM 64",
+		"ppc64le": "IBM Power, little endian",
+		"s390x":   "IBM Z",
+	}
+
+	indexClient = &http.Client{Timeout: 5 * time.Second}
+
+	// indexCache caches the discovered index per ready revision of the kn-cli Knative
+	// Service to avoid fetching it on every reconcile. Failures are only cached until
+	// expires, a fetched index as long as the revision is ready.
+	indexCache = struct {
+		sync.Mutex
+		revision string
+		index    artifactIndex
+		err      error
+		expires  time.Time
+	}{}
+)
+
+// downloadLinks returns the ConsoleCLIDownload links for the given KnativeServing instance. An override
+// set on the instance takes precedence over the artifacts discovered from the kn-cli Knative Service.
+// The links of the default artifacts are returned along with ErrIndexUnavailable if the index failed
+// to be fetched.
+func downloadLinks(instance *servingv1alpha1.KnativeServing, knService *servingv1.Service, baseURL string) ([]consolev1.CLIDownloadLink, error) {
+	if override, ok := instance.GetAnnotations()[LinksAnnotation]; ok {
+		return overrideLinks(override, baseURL)
+	}
+	index, err := discoverIndex(knService)
+	return indexLinks(index, baseURL), err
+}
+
+// overrideLinks parses the links given by the LinksAnnotation.
+func overrideLinks(value, baseURL string) ([]consolev1.CLIDownloadLink, error) {
+	var override []consolev1.CLIDownloadLink
+	if err := json.Unmarshal([]byte(value), &override); err != nil {
+		return nil, fmt.Errorf("%w: failed to parse annotation %q: %v", ErrInvalidLinks, LinksAnnotation, err)
+	}
+	if len(override) == 0 {
+		return nil, fmt.Errorf("%w: annotation %q must contain at least one link", ErrInvalidLinks, LinksAnnotation)
+	}
+	for i := range override {
+		if override[i].Href == "" {
+			return nil, fmt.Errorf("%w: annotation %q contains a link without href", ErrInvalidLinks, LinksAnnotation)
+		}
+		if !strings.Contains(override[i].Href, "://") {
+			override[i].Href = join(baseURL, override[i].Href)
+		}
+	}
+	return override, nil
+}
+
+// indexLinks turns the artifacts of the index into ConsoleCLIDownload links.
+func indexLinks(index artifactIndex, baseURL string) []consolev1.CLIDownloadLink {
+	links := make([]consolev1.CLIDownloadLink, 0, len(index.Artifacts)+1)
+	for _, a := range index.Artifacts {
+		links = append(links, consolev1.CLIDownloadLink{
+			Text: fmt.Sprintf("Download kn for %s for %s", displayName(osNames, a.OS), displayName(archNames, a.Arch)),
+			Href: join(baseURL, a.Path),
+		})
+	}
+	if index.Checksums != "" {
+		links = append(links, consolev1.CLIDownloadLink{
+			Text: "Download kn checksums (SHA-256)",
+			Href: join(baseURL, index.Checksums),
+		})
+	}
+	return links
+}
+
+// discoverIndex fetches the index of available artifacts from the kn-cli Knative Service
+// through its cluster-local address. The default artifacts are returned if the service has
+// no address yet, and along with ErrIndexUnavailable if no valid index is served.
+func discoverIndex(knService *servingv1.Service) (artifactIndex, error) {
+	if knService.Status.Address == nil || knService.Status.Address.URL == nil {
+		return defaultArtifacts, nil
+	}
+	revision := knService.Status.LatestReadyRevisionName
+
+	indexCache.Lock()
+	defer indexCache.Unlock()
+	if revision != "" && indexCache.revision == revision &&
+		(indexCache.expires.IsZero() || time.Now().Before(indexCache.expires)) {
+		return indexCache.index, indexCache.err
+	}
+
+	index, err := fetchIndex(knService.Status.Address.URL.String() + indexPath)
+	indexCache.revision = revision
+	indexCache.expires = time.Time{}
+	if err != nil {
+		log.Info("No kn artifact index available, using default links", "reason", err.Error())
+		index = defaultArtifacts
+		err = fmt.Errorf("%w: %v", ErrIndexUnavailable, err)
+		indexCache.expires = time.Now().Add(FailedIndexTTL)
+	}
+	indexCache.index = index
+	indexCache.err = err
+	return index, err
+}
+
+func fetchIndex(url string) (artifactIndex, error) {
+	index := artifactIndex{}
+	resp, err := indexClient.Get(url)
+	if err != nil {
+		return index, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return index, fmt.Errorf("failed to fetch %s: unexpected status %d", url, resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return index, fmt.Errorf("failed to read %s: %w", url, err)
+	}
+	if err := json.Unmarshal(body, &index); err != nil {
+		return index, fmt.Errorf("failed to parse %s: %w", url, err)
+	}
+	if len(index.Artifacts) == 0 {
+		return index, fmt.Errorf("index %s lists no artifacts", url)
+	}
+	return index, nil
+}
+
+func displayName(names map[string]string, key string) string {
+	if name, ok := names[key]; ok {
+		return name
+	}
+	return key
+}
+
+func join(baseURL, path string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/" + strings.TrimPrefix(path, "/")
+}
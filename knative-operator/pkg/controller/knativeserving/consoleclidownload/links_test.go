@@ -0,0 +1,203 @@
+package consoleclidownload
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	consolev1 "github.com/openshift/api/console/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+const baseURL = "https://kn-cli-knative-serving.example.com"
+
+func TestDownloadLinks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/with-index/index.json":
+			w.Write([]byte(`{
+  "artifacts": [
+    {"os": "linux", "arch": "amd64", "path": "amd64/linux/kn-linux-amd64.tar.gz"},
+    {"os": "linux", "arch": "arm64", "path": "arm64/linux/kn-linux-arm64.tar.gz"},
+    {"os": "linux", "arch": "ppc64le", "path": "ppc64le/linux/kn-linux-ppc64le.tar.gz"},
+    {"os": "linux", "arch": "s390x", "path": "s390x/linux/kn-linux-s390x.tar.gz"},
+    {"os": "macos", "arch": "arm64", "path": "arm64/macos/kn-macos-arm64.tar.gz"}
+  ],
+  "checksums": "checksums.txt"
+}`))
+		case "/empty-index/index.json":
+			w.Write([]byte(`{"artifacts": []}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defaultLinks := []consolev1.CLIDownloadLink{{
+		Text: "Download kn for Linux for x86_64",
+		Href: baseURL + "/amd64/linux/kn-linux-amd64.tar.gz",
+	}, {
+		Text: "Download kn for macOS for x86_64",
+		Href: baseURL + "/amd64/macos/kn-macos-amd64.tar.gz",
+	}, {
+		Text: "Download kn for Windows for x86_64",
+		Href: baseURL + "/amd64/windows/kn-windows-amd64.zip",
+	}}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		address     string
+		revision    string
+		want        []consolev1.CLIDownloadLink
+		wantErr     error
+	}{{
+		name: "no address",
+		want: defaultLinks,
+	}, {
+		name:     "no index served",
+		address:  server.URL + "/no-index",
+		revision: "kn-cli-00001",
+		want:     defaultLinks,
+		wantErr:  ErrIndexUnavailable,
+	}, {
+		name:     "empty index",
+		address:  server.URL + "/empty-index",
+		revision: "kn-cli-00002",
+		want:     defaultLinks,
+		wantErr:  ErrIndexUnavailable,
+	}, {
+		name:     "index",
+		address:  server.URL + "/with-index",
+		revision: "kn-cli-00003",
+		want: []consolev1.CLIDownloadLink{{
+			Text: "Download kn for Linux for x86_64",
+			Href: baseURL + "/amd64/linux/kn-linux-amd64.tar.gz",
+		}, {
+			Text: "Download kn for Linux for ARM 64",
+			Href: baseURL + "/arm64/linux/kn-linux-arm64.tar.gz",
+		}, {
+			Text: "Download kn for Linux for IBM Power, little endian",
+			Href: baseURL + "/ppc64le/linux/kn-linux-ppc64le.tar.gz",
+		}, {
+			Text: "Download kn for Linux for IBM Z",
+			Href: baseURL + "/s390x/linux/kn-linux-s390x.tar.gz",
+		}, {
+			Text: "Download kn for macOS for ARM 64",
+			Href: baseURL + "/arm64/macos/kn-macos-arm64.tar.gz",
+		}, {
+			Text: "Download kn checksums (SHA-256)",
+			Href: baseURL + "/checksums.txt",
+		}},
+	}, {
+		name: "override",
+		annotations: map[string]string{
+			LinksAnnotation: `[{"text": "Download kn for Linux", "href": "/amd64/linux/kn-linux-amd64.tar.gz"},
+{"text": "Download kn from mirror", "href": "https://mirror.example.com/kn.tar.gz"}]`,
+		},
+		address:  server.URL + "/with-index",
+		revision: "kn-cli-00004",
+		want: []consolev1.CLIDownloadLink{{
+			Text: "Download kn for Linux",
+			Href: baseURL + "/amd64/linux/kn-linux-amd64.tar.gz",
+		}, {
+			Text: "Download kn from mirror",
+			Href: "https://mirror.example.com/kn.tar.gz",
+		}},
+	}, {
+		name:        "invalid override",
+		annotations: map[string]string{LinksAnnotation: `{"text": "not a list"}`},
+		wantErr:     ErrInvalidLinks,
+	}, {
+		name:        "empty override",
+		annotations: map[string]string{LinksAnnotation: `[]`},
+		wantErr:     ErrInvalidLinks,
+	}, {
+		name:        "override without href",
+		annotations: map[string]string{LinksAnnotation: `[{"text": "foo"}]`},
+		wantErr:     ErrInvalidLinks,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			instance := &servingv1alpha1.KnativeServing{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "knative-serving",
+					Namespace:   "knative-serving",
+					Annotations: test.annotations,
+				},
+			}
+			service := &servingv1.Service{}
+			service.Status.LatestReadyRevisionName = test.revision
+			if test.address != "" {
+				url, err := apis.ParseURL(test.address)
+				if err != nil {
+					t.Fatalf("Failed to parse URL: %v", err)
+				}
+				service.Status.Address = &duckv1.Addressable{URL: url}
+			}
+
+			got, err := downloadLinks(instance, service, baseURL)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("downloadLinks() = %v, want %v", err, test.wantErr)
+			}
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("Links not as expected, diff: %s", cmp.Diff(test.want, got))
+			}
+		})
+	}
+}
+
+func TestDiscoverIndexRetriesFailures(t *testing.T) {
+	served := false
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if !served {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"artifacts": [{"os": "linux", "arch": "arm64", "path": "arm64/linux/kn-linux-arm64.tar.gz"}]}`))
+	}))
+	defer server.Close()
+
+	url, err := apis.ParseURL(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse URL: %v", err)
+	}
+	service := &servingv1.Service{}
+	service.Status.LatestReadyRevisionName = "kn-cli-retry-00001"
+	service.Status.Address = &duckv1.Addressable{URL: url}
+
+	if got, err := discoverIndex(service); !cmp.Equal(got, defaultArtifacts) || !errors.Is(err, ErrIndexUnavailable) {
+		t.Errorf("discoverIndex() = %v, want ErrIndexUnavailable and the default artifacts, diff: %s", err, cmp.Diff(defaultArtifacts, got))
+	}
+	// The failure is cached for a while only.
+	served = true
+	if _, err := discoverIndex(service); !errors.Is(err, ErrIndexUnavailable) {
+		t.Errorf("discoverIndex() = %v, want the cached ErrIndexUnavailable", err)
+	}
+	if requests != 1 {
+		t.Errorf("Got %d requests, want the failure to be cached", requests)
+	}
+	indexCache.Lock()
+	indexCache.expires = time.Now().Add(-time.Second)
+	indexCache.Unlock()
+
+	want := artifactIndex{Artifacts: []artifact{{OS: "linux", Arch: "arm64", Path: "arm64/linux/kn-linux-arm64.tar.gz"}}}
+	if got, err := discoverIndex(service); err != nil || !cmp.Equal(got, want) {
+		t.Errorf("discoverIndex() = %v, index diff: %s", err, cmp.Diff(want, got))
+	}
+	// A fetched index is cached as long as the revision is ready.
+	discoverIndex(service)
+	if requests != 2 {
+		t.Errorf("Got %d requests, want 2", requests)
+	}
+}
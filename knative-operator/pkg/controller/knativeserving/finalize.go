@@ -0,0 +1,155 @@
+package knativeserving
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/alerts"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/console"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/dashboard"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/knativeserving/consoleclidownload"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/knativeserving/kourier"
+	corev1 "k8s.io/api/core/v1"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// FinalizationTimeoutAnnotation overrides how long failing cleanup steps may block the
+	// deletion of a KnativeServing, e.g. "10m". Afterwards the finalizer is removed anyway and
+	// the resources that couldn't be cleaned up are reported as orphaned.
+	FinalizationTimeoutAnnotation = "serverless.openshift.io/finalization-timeout"
+	// FinalizationTimeoutEnvVar sets the default finalization timeout of the operator.
+	FinalizationTimeoutEnvVar = "SERVING_FINALIZATION_TIMEOUT"
+
+	defaultFinalizationTimeout = 5 * time.Minute
+	// finalizationRetryInterval is the interval to retry failed cleanup steps.
+	finalizationRetryInterval = 30 * time.Second
+)
+
+// cleanupStep removes a part of the resources of a KnativeServing instance.
+type cleanupStep struct {
+	name string
+	run  func(*servingv1alpha1.KnativeServing) error
+}
+
+// general clean-up, mostly resources in different namespaces from servingv1alpha1.KnativeServing.
+// All steps are tried, even if some of them fail. Failures are recorded in the status and as
+// events and retried until the finalization timeout passed.
+func (r *ReconcileKnativeServing) delete(instance *servingv1alpha1.KnativeServing) (reconcile.Result, error) {
+	// Stop telemetry
+	defer r.telemetry.TryStop()
+	// The resources are deleted, so is their drift.
+	defer common.ForgetDrift("serving")
+
+	if !controllerutil.ContainsFinalizer(instance, finalizerName) {
+		log.Info("Finalizer has already been removed, nothing to do")
+		return reconcile.Result{}, nil
+	}
+
+	log.Info("Running cleanup logic")
+	var failures []string
+	for _, step := range r.cleanupSteps() {
+		log.Info("Deleting " + step.name)
+		err := step.run(instance)
+		if errors.Is(err, kourier.ErrIngressNamespaceKept) {
+			log.Info("Keeping ingress namespace", "reason", err.Error())
+			r.event(instance, corev1.EventTypeNormal, "IngressNamespaceKept", err.Error())
+			continue
+		}
+		if err != nil {
+			log.Error(err, "Cleanup step failed", "step", step.name)
+			r.event(instance, corev1.EventTypeWarning, "CleanupFailed", fmt.Sprintf("Failed to delete %s: %v", step.name, err))
+			failures = append(failures, fmt.Sprintf("%s: %v", step.name, err))
+		}
+	}
+
+	if len(failures) == 0 {
+		return reconcile.Result{}, common.RemoveFinalizer(r.client, instance, finalizerName)
+	}
+
+	message := strings.Join(failures, "; ")
+	timeout := finalizationTimeout(instance)
+	remaining := timeout - time.Since(instance.GetDeletionTimestamp().Time)
+	if remaining <= 0 {
+		log.Info("Finalization timed out, removing finalizer", "timeout", timeout, "orphaned", message)
+		r.event(instance, corev1.EventTypeWarning, "OrphanedResources",
+			fmt.Sprintf("Cleanup did not succeed within %v, resources might be orphaned: %s", timeout, message))
+		return reconcile.Result{}, common.RemoveFinalizer(r.client, instance, finalizerName)
+	}
+
+	servingCondSet.Manage(&instance.Status).MarkFalse(CleanupSucceeded, "CleanupFailed", "%s", message)
+	if err := r.client.Status().Update(context.TODO(), instance); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to update status: %w", err)
+	}
+	if remaining > finalizationRetryInterval {
+		remaining = finalizationRetryInterval
+	}
+	return reconcile.Result{RequeueAfter: remaining}, nil
+}
+
+func (r *ReconcileKnativeServing) cleanupSteps() []cleanupStep {
+	return []cleanupStep{{
+		name: "kn ConsoleCLIDownload",
+		run: func(instance *servingv1alpha1.KnativeServing) error {
+			return consoleclidownload.Delete(instance, r.client, r.scheme)
+		},
+	}, {
+		name: "kourier",
+		run: func(instance *servingv1alpha1.KnativeServing) error {
+			return kourier.Delete(instance, r.client, r.discovery, r.scheme)
+		},
+	}, {
+		name: "dashboard",
+		run: func(instance *servingv1alpha1.KnativeServing) error {
+			if err := dashboard.Delete(os.Getenv(dashboard.ServingDashboardPathEnvVar), instance, r.client); err != nil {
+				return err
+			}
+			return dashboard.Delete(os.Getenv(dashboard.ServingSLODashboardPathEnvVar), instance, r.client)
+		},
+	}, {
+		name: "service monitors",
+		run: func(instance *servingv1alpha1.KnativeServing) error {
+			return common.RemoveServingServiceMonitors(r.client, instance)
+		},
+	}, {
+		name: "alerts",
+		run: func(instance *servingv1alpha1.KnativeServing) error {
+			return alerts.Delete(alerts.Path(alerts.ServingAlertsPathEnvVar), servingOwner(instance), r.client)
+		},
+	}, {
+		name: "console resources",
+		run: func(instance *servingv1alpha1.KnativeServing) error {
+			return console.Delete(console.Path(console.ServingConsolePathEnvVar), servingOwner(instance), r.client)
+		},
+	}}
+}
+
+// finalizationTimeout returns the timeout set on the instance, the operator or the default, in that order.
+func finalizationTimeout(instance *servingv1alpha1.KnativeServing) time.Duration {
+	for _, value := range []string{instance.GetAnnotations()[FinalizationTimeoutAnnotation], os.Getenv(FinalizationTimeoutEnvVar)} {
+		if value == "" {
+			continue
+		}
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			log.Error(err, "Ignoring invalid finalization timeout", "value", value)
+			continue
+		}
+		return timeout
+	}
+	return defaultFinalizationTimeout
+}
+
+// event records an event for the instance, if a recorder is set.
+func (r *ReconcileKnativeServing) event(instance *servingv1alpha1.KnativeServing, eventType, reason, message string) {
+	if r.recorder != nil {
+		r.recorder.Event(instance, eventType, reason, message)
+	}
+}
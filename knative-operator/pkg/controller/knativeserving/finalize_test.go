@@ -0,0 +1,100 @@
+package knativeserving
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/dashboard"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name          string
+		dashboardPath string
+		deletedSince  time.Duration
+		annotations   map[string]string
+		wantFinalizer bool
+		wantRequeue   bool
+		wantEvents    []string
+	}{{
+		name:          "all cleanup steps succeed",
+		dashboardPath: os.Getenv(dashboard.ServingDashboardPathEnvVar),
+		deletedSince:  time.Minute,
+	}, {
+		name:          "failing cleanup step is retried",
+		dashboardPath: "testdata/does-not-exist.yaml",
+		deletedSince:  time.Minute,
+		wantFinalizer: true,
+		wantRequeue:   true,
+		wantEvents:    []string{"Warning CleanupFailed"},
+	}, {
+		name:          "finalizer is removed after the timeout",
+		dashboardPath: "testdata/does-not-exist.yaml",
+		deletedSince:  10 * time.Minute,
+		wantEvents:    []string{"Warning CleanupFailed", "Warning OrphanedResources"},
+	}, {
+		name:          "timeout set on the instance",
+		dashboardPath: "testdata/does-not-exist.yaml",
+		deletedSince:  2 * time.Minute,
+		annotations:   map[string]string{FinalizationTimeoutAnnotation: "1m"},
+		wantEvents:    []string{"Warning CleanupFailed", "Warning OrphanedResources"},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer os.Setenv(dashboard.ServingDashboardPathEnvVar, os.Getenv(dashboard.ServingDashboardPathEnvVar))
+			os.Setenv(dashboard.ServingDashboardPathEnvVar, test.dashboardPath)
+
+			ks := defaultKnativeServing.DeepCopy()
+			ks.Annotations = test.annotations
+			ks.Finalizers = []string{finalizerName}
+			deleted := metav1.NewTime(time.Now().Add(-test.deletedSince))
+			ks.DeletionTimestamp = &deleted
+
+			cl := fake.NewFakeClient(ks, &dashboardNamespace)
+			recorder := record.NewFakeRecorder(10)
+			r := &ReconcileKnativeServing{client: cl, scheme: scheme.Scheme, discovery: newFakeDiscovery(), recorder: recorder}
+
+			result, err := r.Reconcile(defaultRequest)
+			if err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+			if requeue := result.RequeueAfter > 0; requeue != test.wantRequeue {
+				t.Errorf("Requeue = %v, want %v", requeue, test.wantRequeue)
+			}
+
+			got := &v1alpha1.KnativeServing{}
+			if err := cl.Get(context.TODO(), defaultRequest.NamespacedName, got); err != nil {
+				t.Fatalf("get: (%v)", err)
+			}
+			if hasFinalizer := len(got.Finalizers) > 0; hasFinalizer != test.wantFinalizer {
+				t.Errorf("Finalizer present = %v, want %v", hasFinalizer, test.wantFinalizer)
+			}
+			if test.wantFinalizer {
+				if cond := got.Status.GetCondition(CleanupSucceeded); cond == nil || cond.Status != corev1.ConditionFalse {
+					t.Errorf("Condition %s = %v, want False", CleanupSucceeded, cond)
+				}
+			}
+
+			close(recorder.Events)
+			var events []string
+			for event := range recorder.Events {
+				if strings.HasPrefix(event, corev1.EventTypeWarning) {
+					events = append(events, strings.Join(strings.Fields(event)[:2], " "))
+				}
+			}
+			if strings.Join(events, ",") != strings.Join(test.wantEvents, ",") {
+				t.Errorf("Events = %v, want %v", events, test.wantEvents)
+			}
+		})
+	}
+}
@@ -2,11 +2,16 @@ package knativeserving
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common/telemetry"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common/tracing"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/alerts"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/console"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/dashboard"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/knativeserving/consoleclidownload"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/knativeserving/kourier"
@@ -14,11 +19,11 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
-	"k8s.io/apimachinery/pkg/api/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/record"
 	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
 	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -46,6 +51,9 @@ const (
 	// certVersionKey is an annotation key used by the Serverless operator to annotate the Knative Serving
 	// controller's PodTemplate to make it redeploy on certificate changes.
 	certVersionKey = "serving.knative.openshift.io/mounted-cert-version"
+
+	// kourierRequeueInterval is the interval to check again if Kourier's deployments are ready.
+	kourierRequeueInterval = 10 * time.Second
 )
 
 var (
@@ -80,8 +88,10 @@ func newReconciler(mgr manager.Manager) *ReconcileKnativeServing {
 	}
 	return &ReconcileKnativeServing{
 		client:    client,
+		discovery: discovery.NewDiscoveryClientForConfigOrDie(mgr.GetConfig()),
 		scheme:    mgr.GetScheme(),
 		mgr:       mgr,
+		recorder:  mgr.GetEventRecorderFor("knativeserving-controller"),
 		telemetry: t,
 	}
 }
@@ -89,7 +99,7 @@ func newReconciler(mgr manager.Manager) *ReconcileKnativeServing {
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r *ReconcileKnativeServing) error {
 	// Create a new controller
-	c, err := controller.New("knativeserving-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("knativeserving-controller", mgr, controller.Options{Reconciler: common.ObservedReconciler("serving", r)})
 	if err != nil {
 		return err
 	}
@@ -126,7 +136,22 @@ func add(mgr manager.Manager, r *ReconcileKnativeServing) error {
 			return err
 		}
 	}
-	return nil
+
+	// Watch the dashboards installed for grafana-operator, as far as they are served by the cluster
+	err = dashboard.Watch(c, mgr, common.EnqueueRequestByOwnerAnnotations(common.ServingOwnerName, common.ServingOwnerNamespace))
+	if err != nil {
+		return err
+	}
+
+	// Watch the alerts, as far as they are served by the cluster
+	err = alerts.Watch(c, mgr, common.EnqueueRequestByOwnerAnnotations(common.ServingOwnerName, common.ServingOwnerNamespace))
+	if err != nil {
+		return err
+	}
+
+	// Watch the console resources, as far as they are served by the cluster
+	return console.Watch(console.Path(console.ServingConsolePathEnvVar), c, mgr,
+		common.EnqueueRequestByOwnerAnnotations(common.ServingOwnerName, common.ServingOwnerNamespace))
 }
 
 // blank assignment to verify that ReconcileKnativeServing implements reconcile.Reconciler
@@ -137,9 +162,15 @@ type ReconcileKnativeServing struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
 	client    client.Client
+	discovery discovery.DiscoveryInterface
 	mgr       manager.Manager
 	scheme    *runtime.Scheme
+	recorder  record.EventRecorder
 	telemetry *telemetry.Telemetry
+	// trace records the reconciliation, if set.
+	trace *tracing.Reconciliation
+	// drifts reports the drift of the resources applied in the reconciliation.
+	drifts *common.DriftReporter
 }
 
 // Reconcile reads that state of the cluster for a KnativeServing
@@ -147,25 +178,29 @@ func (r *ReconcileKnativeServing) Reconcile(request reconcile.Request) (reconcil
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling KnativeServing")
 
+	trace := tracing.StartReconciliation("KnativeServing", request.NamespacedName)
+	defer trace.End()
+	traced := r.traced(trace)
+
 	// Fetch the KnativeServing instance
 	original := &servingv1alpha1.KnativeServing{}
 	err := r.client.Get(context.TODO(), request.NamespacedName, original)
 	if err != nil {
-		if errors.IsNotFound(err) {
+		if apierrors.IsNotFound(err) {
 			return reconcile.Result{}, nil
 		}
 		return reconcile.Result{}, err
 	}
 
 	if original.GetDeletionTimestamp() != nil {
-		return reconcile.Result{}, r.delete(original)
+		return traced.delete(original.DeepCopy())
 	}
 
 	instance := original.DeepCopy()
-	reconcileErr := r.reconcileKnativeServing(instance)
+	result, reconcileErr := traced.reconcileKnativeServing(instance)
 
 	if !equality.Semantic.DeepEqual(original.Status, instance.Status) {
-		if err := r.client.Status().Update(context.TODO(), instance); err != nil {
+		if err := traced.client.Status().Update(context.TODO(), instance); err != nil {
 			return reconcile.Result{}, fmt.Errorf("failed to update status: %w", err)
 		}
 	}
@@ -178,25 +213,60 @@ func (r *ReconcileKnativeServing) Reconcile(request reconcile.Request) (reconcil
 	} else {
 		common.KnativeServingUpG.Set(0)
 	}
-	return reconcile.Result{}, reconcileErr
+	return result, reconcileErr
 }
 
-func (r *ReconcileKnativeServing) reconcileKnativeServing(instance *servingv1alpha1.KnativeServing) error {
-	stages := []func(*servingv1alpha1.KnativeServing) error{
-		r.configure,
-		r.ensureFinalizers,
-		r.ensureCustomCertsConfigMap,
-		r.installKourier,
-		r.installDashboard,
-		r.ensureProxySettings,
-		r.installKnConsoleCLIDownload,
-	}
-	for _, stage := range stages {
-		if err := stage(instance); err != nil {
-			return err
-		}
-	}
-	return nil
+func (r *ReconcileKnativeServing) reconcileKnativeServing(instance *servingv1alpha1.KnativeServing) (reconcile.Result, error) {
+	conditions := servingCondSet.Manage(&instance.Status)
+	if common.ReportReconcilePaused(instance, conditions, common.KnativeServingPausedG) {
+		return reconcile.Result{}, nil
+	}
+
+	pipeline := common.Pipeline{
+		Component:  "serving",
+		Conditions: conditions,
+		Trace:      r.trace,
+	}
+	return pipeline.Run(
+		common.Stage{Name: "configure", Run: func() common.StageResult {
+			return common.Error(r.configure(instance))
+		}},
+		common.Stage{Name: "finalizers", Run: func() common.StageResult {
+			return common.Error(common.EnsureFinalizer(r.client, instance, finalizerName))
+		}},
+		common.Stage{Name: "custom-certs", Condition: CustomCertsReady, Reason: "ReconcileFailed", Run: func() common.StageResult {
+			return common.Error(r.ensureCustomCertsConfigMap(instance))
+		}},
+		common.Stage{Name: "kourier", Condition: KourierReady, Reason: "InstallFailed", Run: func() common.StageResult {
+			return r.installKourier(instance)
+		}},
+		common.Stage{Name: "dashboard", Condition: DashboardInstalled, Reason: "InstallFailed", Run: func() common.StageResult {
+			return common.Error(r.installDashboard(instance))
+		}},
+		common.Stage{Name: "service-monitors", Condition: ServiceMonitorsInstalled, Reason: "InstallFailed", Run: func() common.StageResult {
+			return common.Error(r.installServiceMonitors(instance))
+		}},
+		common.Stage{Name: "alerts", Condition: AlertsInstalled, Reason: "InstallFailed", Run: func() common.StageResult {
+			return common.Error(r.installAlerts(instance))
+		}},
+		common.Stage{Name: "proxy", Condition: ProxySettingsReady, Reason: "ReconcileFailed", Run: func() common.StageResult {
+			return common.Error(r.ensureProxySettings(instance))
+		}},
+		common.Stage{Name: "cli-download", Condition: CLIDownloadReady, Reason: "InstallFailed", Run: func() common.StageResult {
+			return r.installKnConsoleCLIDownload(instance)
+		}},
+		common.Stage{Name: "console", Condition: ConsoleResourcesInstalled, Reason: "InstallFailed", Run: func() common.StageResult {
+			return common.Error(r.installConsoleResources(instance))
+		}},
+		// The stage marks the ImagesPinned condition itself, as it reflects the inventory rather than the stage.
+		common.Stage{Name: "images", Run: func() common.StageResult {
+			return common.Error(r.reportImages(instance))
+		}},
+		common.Stage{Name: "drift", Run: func() common.StageResult {
+			r.drift(instance).MarkResourcesInSync(conditions)
+			return common.Done()
+		}},
+	)
 }
 
 // configure default settings for OpenShift
@@ -227,18 +297,6 @@ func (r *ReconcileKnativeServing) ensureProxySettings(instance *servingv1alpha1.
 	return common.ApplyEnvironmentToDeployment(instance.Namespace, "controller", proxyEnv, r.client)
 }
 
-// set a finalizer to clean up service mesh when instance is deleted
-func (r *ReconcileKnativeServing) ensureFinalizers(instance *servingv1alpha1.KnativeServing) error {
-	for _, finalizer := range instance.GetFinalizers() {
-		if finalizer == finalizerName {
-			return nil
-		}
-	}
-	log.Info("Adding finalizer")
-	instance.SetFinalizers(append(instance.GetFinalizers(), finalizerName))
-	return r.client.Update(context.TODO(), instance)
-}
-
 // create the configmap to be injected with custom certs
 func (r *ReconcileKnativeServing) ensureCustomCertsConfigMap(instance *servingv1alpha1.KnativeServing) error {
 	certs := instance.Spec.ControllerCustomCerts
@@ -276,7 +334,7 @@ func (r *ReconcileKnativeServing) ensureCustomCertsConfigMap(instance *servingv1
 	controller := &appsv1.Deployment{}
 	err = r.client.Get(context.TODO(), client.ObjectKey{Namespace: instance.Namespace, Name: "controller"}, controller)
 	// If the controller doesn't yet exist, exit early.
-	if errors.IsNotFound(err) {
+	if apierrors.IsNotFound(err) {
 		return nil
 	} else if err != nil {
 		return fmt.Errorf("error fetching controller deployment: %w", err)
@@ -307,7 +365,7 @@ func (r *ReconcileKnativeServing) reconcileConfigMap(instance *servingv1alpha1.K
 	ctx := context.TODO()
 	cm := &corev1.ConfigMap{}
 	err := r.client.Get(ctx, client.ObjectKey{Name: name, Namespace: instance.GetNamespace()}, cm)
-	if errors.IsNotFound(err) {
+	if apierrors.IsNotFound(err) {
 		cm.Name = name
 		cm.Namespace = instance.GetNamespace()
 		cm.Annotations = annotations
@@ -355,66 +413,109 @@ func (r *ReconcileKnativeServing) reconcileConfigMap(instance *servingv1alpha1.K
 }
 
 // Install Kourier Ingress Gateway
-func (r *ReconcileKnativeServing) installKourier(instance *servingv1alpha1.KnativeServing) error {
+func (r *ReconcileKnativeServing) installKourier(instance *servingv1alpha1.KnativeServing) common.StageResult {
+	// The webhook rejects invalid settings, unless it was bypassed or the instance predates it.
+	if err := common.ValidateIngressNamespace(instance); err != nil {
+		instance.Status.MarkDependencyInstalling("Kourier")
+		return common.Fatal("InvalidIngressNamespace", err)
+	}
 	// install Kourier
-	if err := kourier.Apply(instance, r.client, r.scheme); err != nil {
+	if err := kourier.Apply(instance, r.drift(instance).Client(r.client), r.discovery, r.scheme); err != nil {
 		instance.Status.MarkDependencyInstalling("Kourier")
-		return err
+		if errors.Is(err, kourier.ErrDeploymentsNotReady) {
+			return common.RequeueAfter(kourierRequeueInterval, "DeploymentsNotReady", "%v", err)
+		}
+		return common.Error(err)
 	}
 	instance.Status.MarkDependenciesInstalled()
-	return nil
+	return common.Done()
 }
 
-// installKnConsoleCLIDownload creates CR for kn CLI download link
-func (r *ReconcileKnativeServing) installKnConsoleCLIDownload(instance *servingv1alpha1.KnativeServing) error {
-	return consoleclidownload.Apply(instance, r.client, r.scheme)
+// reportImages reports the images of the Serving deployments and Kourier.
+func (r *ReconcileKnativeServing) reportImages(instance *servingv1alpha1.KnativeServing) error {
+	list := &appsv1.DeploymentList{}
+	if err := r.client.List(context.TODO(), list, client.InNamespace(instance.Namespace)); err != nil {
+		return fmt.Errorf("failed to list deployments: %w", err)
+	}
+	// Only the deployments created by the upstream operator for the instance.
+	var deployments []appsv1.Deployment
+	for _, d := range list.Items {
+		if metav1.IsControlledBy(&d, instance) {
+			deployments = append(deployments, d)
+		}
+	}
+	overrides := common.ContainerImageOverrides(common.BuildImageOverrideMapFromEnviron(os.Environ(), "IMAGE_"))
+	images := common.DeploymentImages(deployments, overrides)
+
+	kourierImages, err := kourier.Images(instance, r.client, r.scheme)
+	if err != nil {
+		return fmt.Errorf("failed to determine kourier images: %w", err)
+	}
+	return common.ReportImages("serving", servingCondSet.Manage(&instance.Status), &instance.Status.Status, append(images, kourierImages...))
 }
 
-// installDashboard installs dashboard for OpenShift webconsole
-func (r *ReconcileKnativeServing) installDashboard(instance *servingv1alpha1.KnativeServing) error {
-	return dashboard.Apply(os.Getenv("SERVING_DASHBOARD_MANIFEST_PATH"), instance, r.client)
+// installKnConsoleCLIDownload creates CR for kn CLI download link
+func (r *ReconcileKnativeServing) installKnConsoleCLIDownload(instance *servingv1alpha1.KnativeServing) common.StageResult {
+	err := consoleclidownload.Apply(instance, r.client, r.scheme)
+	switch {
+	case errors.Is(err, consoleclidownload.ErrInvalidLinks):
+		return common.Fatal("InvalidLinks", err)
+	case errors.Is(err, consoleclidownload.ErrIndexUnavailable):
+		// The default links are installed meanwhile.
+		return common.RequeueAfter(consoleclidownload.FailedIndexTTL, "IndexUnavailable", "%v", err)
+	}
+	return common.Error(err)
 }
 
-// general clean-up, mostly resources in different namespaces from servingv1alpha1.KnativeServing.
-func (r *ReconcileKnativeServing) delete(instance *servingv1alpha1.KnativeServing) error {
-	// Stop telemetry
-	defer r.telemetry.TryStop()
-	finalizers := sets.NewString(instance.GetFinalizers()...)
+// installConsoleResources installs YAML samples and quick starts for OpenShift webconsole
+func (r *ReconcileKnativeServing) installConsoleResources(instance *servingv1alpha1.KnativeServing) error {
+	return console.Apply(console.Path(console.ServingConsolePathEnvVar), servingOwner(instance), r.drift(instance).Client(r.client))
+}
 
-	if !finalizers.Has(finalizerName) {
-		log.Info("Finalizer has already been removed, nothing to do")
-		return nil
+// installDashboard installs the Serving and the per-service SLO dashboards for OpenShift webconsole
+func (r *ReconcileKnativeServing) installDashboard(instance *servingv1alpha1.KnativeServing) error {
+	api := r.drift(instance).Client(r.client)
+	if err := r.trace.Apply("dashboard", func() error {
+		return dashboard.Apply(os.Getenv(dashboard.ServingDashboardPathEnvVar), instance, api)
+	}); err != nil {
+		return err
 	}
+	return r.trace.Apply("slo-dashboard", func() error {
+		return dashboard.Apply(os.Getenv(dashboard.ServingSLODashboardPathEnvVar), instance, api)
+	})
+}
 
-	log.Info("Running cleanup logic")
-	log.Info("Deleting kn ConsoleCLIDownload")
-	if err := consoleclidownload.Delete(instance, r.client, r.scheme); err != nil {
-		return fmt.Errorf("failed to delete kn ConsoleCLIDownload: %w", err)
-	}
+// installServiceMonitors installs the service monitors of the Serving control plane
+func (r *ReconcileKnativeServing) installServiceMonitors(instance *servingv1alpha1.KnativeServing) error {
+	return common.SetupServingServiceMonitors(r.drift(instance).Client(r.client), instance)
+}
 
-	log.Info("Deleting kourier")
-	if err := kourier.Delete(instance, r.client, r.scheme); err != nil {
-		return fmt.Errorf("failed to delete kourier: %w", err)
-	}
+// installAlerts installs the alerting rules for the cluster monitoring
+func (r *ReconcileKnativeServing) installAlerts(instance *servingv1alpha1.KnativeServing) error {
+	return alerts.Apply(alerts.Path(alerts.ServingAlertsPathEnvVar), instance, servingOwner(instance), r.drift(instance).Client(r.client))
+}
 
-	log.Info("Deleting dashboard")
-	if err := dashboard.Delete(os.Getenv(dashboard.ServingDashboardPathEnvVar), instance, r.client); err != nil {
-		return fmt.Errorf("failed to delete dashboard configmap: %w", err)
-	}
+// traced returns a copy of the reconciler recording its stages and API calls in the given trace.
+func (r *ReconcileKnativeServing) traced(trace *tracing.Reconciliation) *ReconcileKnativeServing {
+	traced := *r
+	traced.client = trace.Client(r.client)
+	traced.trace = trace
+	traced.drifts = nil
+	return &traced
+}
 
-	// The above might take a while, so we refetch the resource again in case it has changed.
-	refetched := &servingv1alpha1.KnativeServing{}
-	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}, refetched); err != nil {
-		return fmt.Errorf("failed to refetch KnativeServing: %w", err)
+// drift returns the reporter of drift of the resources applied for the instance. The reporter is shared by
+// the stages of a reconciliation, so that the drift kept by any of them is reflected in the status.
+func (r *ReconcileKnativeServing) drift(instance *servingv1alpha1.KnativeServing) *common.DriftReporter {
+	if r.drifts == nil || r.drifts.Instance != instance {
+		r.drifts = &common.DriftReporter{Component: "serving", Instance: instance, Recorder: r.recorder}
 	}
+	return r.drifts
+}
 
-	// Update the refetched finalizer list.
-	finalizers = sets.NewString(refetched.GetFinalizers()...)
-	finalizers.Delete(finalizerName)
-	refetched.SetFinalizers(finalizers.List())
-
-	if err := r.client.Update(context.TODO(), refetched); err != nil {
-		return fmt.Errorf("failed to update KnativeServing with removed finalizer: %w", err)
+func servingOwner(instance *servingv1alpha1.KnativeServing) map[string]string {
+	return map[string]string{
+		common.ServingOwnerName:      instance.Name,
+		common.ServingOwnerNamespace: instance.Namespace,
 	}
-	return nil
 }
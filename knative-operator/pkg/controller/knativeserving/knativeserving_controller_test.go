@@ -5,8 +5,12 @@ import (
 	"os"
 	"testing"
 
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/google/go-cmp/cmp"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/alerts"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/console"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/dashboard"
 	configv1 "github.com/openshift/api/config/v1"
 	consolev1 "github.com/openshift/api/console/v1"
@@ -16,7 +20,9 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
 	"knative.dev/operator/pkg/apis/operator/v1alpha1"
 	pkgapis "knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
@@ -25,6 +31,11 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
+// newFakeDiscovery returns a discovery client serving no kinds of namespaced objects.
+func newFakeDiscovery() *fakediscovery.FakeDiscovery {
+	return &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+}
+
 var (
 	defaultKnativeServing = v1alpha1.KnativeServing{
 		ObjectMeta: metav1.ObjectMeta{
@@ -103,6 +114,12 @@ var (
 		},
 	}
 
+	servingNamespace = corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "knative-serving",
+		},
+	}
+
 	dashboardNamespace = corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: dashboard.ConfigManagedNamespace,
@@ -114,6 +131,11 @@ func init() {
 	os.Setenv("OPERATOR_NAME", "TEST_OPERATOR")
 	os.Setenv("KOURIER_MANIFEST_PATH", "kourier/testdata/kourier-latest.yaml")
 	os.Setenv(dashboard.ServingDashboardPathEnvVar, "../dashboard/testdata/grafana-dash-knative.yaml")
+	os.Setenv(dashboard.ServingSLODashboardPathEnvVar, "../../../deploy/resources/dashboards/grafana-dash-knative-service-slo.yaml")
+	os.Setenv(console.ServingConsolePathEnvVar, "../../../deploy/resources/console/serving-console.yaml")
+	os.Setenv(alerts.ServingAlertsPathEnvVar, "../../../deploy/resources/alerts/serving-alerts.yaml")
+	os.Setenv(common.TestServingServiceMonitorPath, "../../../deploy/resources/serving-service-monitors.yaml")
+	os.Setenv(common.TestRolePath, "../dashboard/testdata/role_service_monitor.yaml")
 
 	apis.AddToScheme(scheme.Scheme)
 }
@@ -153,10 +175,10 @@ func TestKourierReconcile(t *testing.T) {
 			ccd := &consolev1.ConsoleCLIDownload{}
 			ns := &dashboardNamespace
 			knService := &defaultKnService
-			initObjs := []runtime.Object{ks, ingress, ns, knService}
+			initObjs := []runtime.Object{ks, ingress, ns, &servingNamespace, knService}
 
 			cl := fake.NewFakeClient(initObjs...)
-			r := &ReconcileKnativeServing{client: cl, scheme: scheme.Scheme}
+			r := &ReconcileKnativeServing{client: cl, scheme: scheme.Scheme, discovery: newFakeDiscovery()}
 
 			// Reconcile to initialize
 			if _, err := r.Reconcile(defaultRequest); err != nil {
@@ -183,6 +205,23 @@ func TestKourierReconcile(t *testing.T) {
 				t.Fatalf("get: (%v)", err)
 			}
 
+			// Check if the control plane service monitors are installed
+			for _, component := range []string{"activator", "autoscaler", "controller", "webhook"} {
+				sm := &monitoringv1.ServiceMonitor{}
+				err = cl.Get(context.TODO(), types.NamespacedName{Name: "knative-serving-metrics-" + component, Namespace: "knative-serving"}, sm)
+				if err != nil {
+					t.Fatalf("get: (%v)", err)
+				}
+				svc := &corev1.Service{}
+				err = cl.Get(context.TODO(), types.NamespacedName{Name: component + "-sm-service", Namespace: "knative-serving"}, svc)
+				if err != nil {
+					t.Fatalf("get: (%v)", err)
+				}
+				if svc.Spec.Selector["app"] != component {
+					t.Fatalf("got %q, want %q", svc.Spec.Selector["app"], component)
+				}
+			}
+
 			// Delete Kourier deployment.
 			err = cl.Delete(context.TODO(), deploy)
 			if err != nil {
@@ -321,7 +360,7 @@ func TestCustomCertsConfigMap(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			cl := fake.NewFakeClient(test.in...)
-			r := &ReconcileKnativeServing{client: cl, scheme: scheme.Scheme}
+			r := &ReconcileKnativeServing{client: cl, scheme: scheme.Scheme, discovery: newFakeDiscovery()}
 
 			if err := r.ensureCustomCertsConfigMap(ks); err != nil {
 				t.Fatal(err)
@@ -364,10 +403,10 @@ func TestKnativeServingStatus(t *testing.T) {
 	ingress := &defaultIngress
 	knService := &defaultKnService
 
-	initObjs := []runtime.Object{ks, ingress, knService}
+	initObjs := []runtime.Object{ks, ingress, &servingNamespace, knService}
 
 	cl := fake.NewFakeClient(initObjs...)
-	r := &ReconcileKnativeServing{client: cl, scheme: scheme.Scheme}
+	r := &ReconcileKnativeServing{client: cl, scheme: scheme.Scheme, discovery: newFakeDiscovery()}
 
 	// Test with invalid Kourier manifest file.
 	os.Setenv("KOURIER_MANIFEST_PATH", "kourier/testdata/non-exist-file")
@@ -437,3 +476,90 @@ func cm(name string, labels, annotations, data map[string]string, resourceVersio
 		Data: data,
 	}
 }
+
+// TestStageConditions runs Reconcile to verify that the stages are reflected in the status.
+func TestStageConditions(t *testing.T) {
+	tests := []struct {
+		name          string
+		dashboardPath string
+		annotations   map[string]string
+		want          map[pkgapis.ConditionType]corev1.ConditionStatus
+		wantErr       bool
+	}{{
+		name:          "all stages succeed",
+		dashboardPath: os.Getenv(dashboard.ServingDashboardPathEnvVar),
+		want: map[pkgapis.ConditionType]corev1.ConditionStatus{
+			common.ReconcileActive:    corev1.ConditionTrue,
+			CustomCertsReady:          corev1.ConditionTrue,
+			KourierReady:              corev1.ConditionTrue,
+			DashboardInstalled:        corev1.ConditionTrue,
+			ServiceMonitorsInstalled:  corev1.ConditionTrue,
+			AlertsInstalled:           corev1.ConditionTrue,
+			ProxySettingsReady:        corev1.ConditionTrue,
+			CLIDownloadReady:          corev1.ConditionTrue,
+			ConsoleResourcesInstalled: corev1.ConditionTrue,
+		},
+	}, {
+		name:          "dashboard fails",
+		dashboardPath: "testdata/does-not-exist.yaml",
+		want: map[pkgapis.ConditionType]corev1.ConditionStatus{
+			common.ReconcileActive: corev1.ConditionTrue,
+			CustomCertsReady:       corev1.ConditionTrue,
+			KourierReady:           corev1.ConditionTrue,
+			DashboardInstalled:     corev1.ConditionFalse,
+		},
+		wantErr: true,
+	}, {
+		// Invalid settings fail the stage without retrying.
+		name:          "invalid ingress namespace labels",
+		dashboardPath: os.Getenv(dashboard.ServingDashboardPathEnvVar),
+		annotations:   map[string]string{common.IngressNamespaceLabelsAnnotation: "not json"},
+		want: map[pkgapis.ConditionType]corev1.ConditionStatus{
+			common.ReconcileActive: corev1.ConditionTrue,
+			CustomCertsReady:       corev1.ConditionTrue,
+			KourierReady:           corev1.ConditionFalse,
+		},
+	}, {
+		name:          "reconciliation paused",
+		dashboardPath: os.Getenv(dashboard.ServingDashboardPathEnvVar),
+		annotations:   map[string]string{common.ReconcileAnnotation: common.ReconcilePaused},
+		want: map[pkgapis.ConditionType]corev1.ConditionStatus{
+			common.ReconcileActive: corev1.ConditionFalse,
+		},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer os.Setenv(dashboard.ServingDashboardPathEnvVar, os.Getenv(dashboard.ServingDashboardPathEnvVar))
+			os.Setenv(dashboard.ServingDashboardPathEnvVar, test.dashboardPath)
+
+			ks := defaultKnativeServing.DeepCopy()
+			ks.Annotations = test.annotations
+			cl := fake.NewFakeClient(ks, &defaultIngress, &dashboardNamespace, &servingNamespace, &defaultKnService)
+			r := &ReconcileKnativeServing{client: cl, scheme: scheme.Scheme, discovery: newFakeDiscovery()}
+
+			if _, err := r.Reconcile(defaultRequest); (err != nil) != test.wantErr {
+				t.Errorf("Reconcile() = %v, wantErr %v", err, test.wantErr)
+			}
+
+			got := &v1alpha1.KnativeServing{}
+			if err := cl.Get(context.TODO(), defaultRequest.NamespacedName, got); err != nil {
+				t.Fatalf("get: (%v)", err)
+			}
+			for _, c := range []pkgapis.ConditionType{common.ReconcileActive, CustomCertsReady, KourierReady, DashboardInstalled,
+				ServiceMonitorsInstalled, AlertsInstalled, ProxySettingsReady, CLIDownloadReady, ConsoleResourcesInstalled} {
+				cond := got.Status.GetCondition(c)
+				want, ok := test.want[c]
+				if !ok {
+					if cond != nil {
+						t.Errorf("Condition %s = %v, want none", c, cond)
+					}
+					continue
+				}
+				if cond == nil || cond.Status != want {
+					t.Errorf("Condition %s = %v, want status %s", c, cond, want)
+				}
+			}
+		})
+	}
+}
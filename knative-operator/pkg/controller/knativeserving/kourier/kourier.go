@@ -2,8 +2,10 @@ package kourier
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	mfc "github.com/manifestival/controller-runtime-client"
 	mf "github.com/manifestival/manifestival"
@@ -11,21 +13,53 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
 	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
 )
 
 var log = common.Log.WithName("kourier")
 
-// Apply applies Kourier resources.
-func Apply(instance *servingv1alpha1.KnativeServing, api client.Client, scheme *runtime.Scheme) error {
-	manifest, err := manifest(common.IngressNamespace(instance.GetNamespace()), api, instance, scheme)
+var (
+	// ErrDeploymentsNotReady is returned by Apply if Kourier is installed but its deployments are not ready yet.
+	ErrDeploymentsNotReady = errors.New("deployments not ready")
+	// ErrIngressNamespaceKept is returned by Delete if the ingress namespace is not safe to delete.
+	ErrIngressNamespaceKept = errors.New("ingress namespace kept")
+)
+
+const (
+	// manifestivalAnnotation is set to manifestivalCreated by Manifestival on the resources it creates.
+	manifestivalAnnotation = "manifestival"
+	manifestivalCreated    = "new"
+
+	// bootstrapConfigMap holds the Envoy bootstrap of the gateway, under bootstrapKey.
+	bootstrapConfigMap = "kourier-bootstrap"
+	bootstrapKey       = "envoy-bootstrap.yaml"
+	// xdsCluster is the bootstrap cluster the gateway gets its configuration from, served by controlService.
+	xdsCluster     = "xds_cluster"
+	controlService = "kourier-control"
+)
+
+// Apply applies Kourier resources. Once Kourier is ready, the resources in ingress namespaces
+// previously used by the instance are removed. The discovery client finds the kinds of objects
+// to check these namespaces for before deleting them.
+func Apply(instance *servingv1alpha1.KnativeServing, api client.Client, dc discovery.DiscoveryInterface, scheme *runtime.Scheme) error {
+	namespace := common.IngressNamespace(instance)
+	// Record the namespace before applying anything, so it's cleaned up even if it's changed again
+	// before Kourier became ready.
+	if err := recordAppliedNamespaces(append(common.AppliedIngressNamespaces(instance), namespace), instance, api); err != nil {
+		return err
+	}
+	manifest, err := manifest(namespace, api, instance, scheme)
 	if err != nil {
 		return fmt.Errorf("failed to load kourier manifest: %w", err)
 	}
-	log.Info("Installing Kourier Ingress")
+	log.Info("Installing Kourier Ingress", "namespace", namespace)
 	if err := manifest.Apply(); err != nil {
 		return fmt.Errorf("failed to apply kourier manifest: %w", err)
 	}
@@ -33,9 +67,88 @@ func Apply(instance *servingv1alpha1.KnativeServing, api client.Client, scheme *
 		return fmt.Errorf("failed to check deployments: %w", err)
 	}
 	log.Info("Kourier is ready")
+	if err := deletePreviousNamespaces(namespace, instance, api, dc, scheme); err != nil {
+		return fmt.Errorf("failed to migrate from previous ingress namespace: %w", err)
+	}
+	return recordAppliedNamespaces([]string{namespace}, instance, api)
+}
+
+// Images returns the image inventory of the live Kourier deployments. Kourier images are configured
+// per deployment rather than per container.
+func Images(instance *servingv1alpha1.KnativeServing, api client.Client, scheme *runtime.Scheme) ([]common.ContainerImage, error) {
+	manifest, err := manifest(common.IngressNamespace(instance), api, instance, scheme)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kourier manifest: %w", err)
+	}
+	overrideMap := common.BuildImageOverrideMapFromEnviron(os.Environ(), "IMAGE_")
+	overrides := func(workload, _ string) (string, bool) {
+		image, ok := overrideMap[workload]
+		return image, ok
+	}
+	return common.WorkloadImages(api, manifest.Resources(), overrides)
+}
+
+// deletePreviousNamespaces deletes Kourier from the ingress namespaces the instance used before
+// the current one. These namespaces are deleted as well, if it's safe to remove them.
+func deletePreviousNamespaces(current string, instance *servingv1alpha1.KnativeServing, api client.Client, dc discovery.DiscoveryInterface, scheme *runtime.Scheme) error {
+	for _, ns := range common.AppliedIngressNamespaces(instance) {
+		if ns == current {
+			continue
+		}
+		log.Info("Migrating from previous ingress namespace", "namespace", ns, "current", current)
+		err := deleteFromNamespace(ns, namespaced, instance, api, dc, scheme)
+		if errors.Is(err, ErrIngressNamespaceKept) {
+			log.Info("Keeping previous ingress namespace", "reason", err.Error())
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// recordAppliedNamespaces records the given ingress namespaces on the instance, if they changed.
+// Only the annotation is patched, so the status the reconciliation computed so far is kept.
+func recordAppliedNamespaces(namespaces []string, instance *servingv1alpha1.KnativeServing, api client.Client) error {
+	var unique []string
+	seen := map[string]bool{}
+	for _, ns := range namespaces {
+		if !seen[ns] {
+			seen[ns] = true
+			unique = append(unique, ns)
+		}
+	}
+	value := strings.Join(unique, ",")
+	if current, ok := instance.GetAnnotations()[common.AppliedIngressNamespacesAnnotation]; ok && current == value {
+		return nil
+	}
+	patched := instance.DeepCopy()
+	annotations := patched.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[common.AppliedIngressNamespacesAnnotation] = value
+	patched.SetAnnotations(annotations)
+	if err := api.Patch(context.TODO(), patched, client.MergeFrom(instance)); err != nil {
+		return fmt.Errorf("failed to record the applied ingress namespaces: %w", err)
+	}
+	instance.SetAnnotations(patched.GetAnnotations())
+	instance.SetResourceVersion(patched.GetResourceVersion())
+	return nil
+}
+
+// namespaced selects the resources of the manifest that have been put in the ingress namespace.
+// The cluster-scoped resources are shared with the current ingress namespace.
+func namespaced(u *unstructured.Unstructured) bool {
+	return u.GetNamespace() != ""
+}
+
+// all selects all resources of the manifest.
+func all(*unstructured.Unstructured) bool {
+	return true
+}
+
 // Check for deployments in knative-serving-ingress
 // This function is copied from knativeserving_controller.go in serving-operator
 func checkDeployments(manifest *mf.Manifest, api client.Client) error {
@@ -43,45 +156,180 @@ func checkDeployments(manifest *mf.Manifest, api client.Client) error {
 	for _, u := range manifest.Filter(mf.ByKind("Deployment")).Resources() {
 		deployment := &appsv1.Deployment{}
 		err := api.Get(context.TODO(), client.ObjectKey{Namespace: u.GetNamespace(), Name: u.GetName()}, deployment)
-		if err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("deployment %q/%q not found: %w", u.GetName(), u.GetNamespace(), ErrDeploymentsNotReady)
+		} else if err != nil {
 			return err
 		}
 		for _, c := range deployment.Status.Conditions {
 			if c.Type == appsv1.DeploymentAvailable && c.Status != v1.ConditionTrue {
-				return fmt.Errorf("deployment %q/%q not ready", u.GetName(), u.GetNamespace())
+				return fmt.Errorf("deployment %q/%q: %w", u.GetName(), u.GetNamespace(), ErrDeploymentsNotReady)
 			}
 		}
 	}
 	return nil
 }
 
-// Delete deletes Kourier resources.
-func Delete(instance *servingv1alpha1.KnativeServing, api client.Client, scheme *runtime.Scheme) error {
+// Delete deletes Kourier resources and the ingress namespace, if it's safe to remove it.
+// ErrIngressNamespaceKept is returned if the ingress namespace is kept.
+func Delete(instance *servingv1alpha1.KnativeServing, api client.Client, dc discovery.DiscoveryInterface, scheme *runtime.Scheme) error {
 	log.Info("Deleting Kourier Ingress")
-	manifest, err := manifest(common.IngressNamespace(instance.GetNamespace()), api, instance, scheme)
+	namespace := common.IngressNamespace(instance)
+	// Clean up namespaces left behind by a migration that didn't finish.
+	if err := deletePreviousNamespaces(namespace, instance, api, dc, scheme); err != nil {
+		return err
+	}
+	return deleteFromNamespace(namespace, all, instance, api, dc, scheme)
+}
+
+// deleteFromNamespace deletes the Kourier resources selected by the predicate for the given
+// ingress namespace and the namespace itself, if it's safe to remove it.
+func deleteFromNamespace(namespace string, predicate mf.Predicate, instance *servingv1alpha1.KnativeServing, api client.Client, dc discovery.DiscoveryInterface, scheme *runtime.Scheme) error {
+	manifest, err := manifest(namespace, api, instance, scheme)
 	if err != nil {
 		return fmt.Errorf("failed to load kourier manifest: %w", err)
 	}
 
-	if err := manifest.Delete(); err != nil {
-		return fmt.Errorf("failed to delete kourier manifest: %w", err)
+	// Manifestival skips the namespace as it's not marked as created in the manifest itself.
+	if err := manifest.Filter(predicate).Delete(); err != nil {
+		return fmt.Errorf("failed to delete kourier from namespace %q: %w", namespace, err)
 	}
+	return deleteIngressNamespace(namespace, instance, api, dc)
+}
 
-	log.Info("Deleting ingress namespace")
+// deleteIngressNamespace deletes the ingress namespace only if it has been created by the
+// operator for the given instance and doesn't contain any objects not managed by the operator.
+func deleteIngressNamespace(name string, instance *servingv1alpha1.KnativeServing, api client.Client, dc discovery.DiscoveryInterface) error {
 	ns := &v1.Namespace{}
-	err = api.Get(context.TODO(), client.ObjectKey{Name: common.IngressNamespace(instance.GetNamespace())}, ns)
+	err := api.Get(context.TODO(), client.ObjectKey{Name: name}, ns)
 	if apierrors.IsNotFound(err) {
 		// We can safely ignore this. There is nothing to do for us.
 		return nil
 	} else if err != nil {
 		return fmt.Errorf("failed to fetch ingress namespace: %w", err)
 	}
-	if err := api.Delete(context.TODO(), ns); err != nil {
+
+	if !ownedBy(ns, instance) {
+		return fmt.Errorf("namespace %q was not created by the operator: %w", name, ErrIngressNamespaceKept)
+	}
+	kinds, err := namespacedKinds(dc)
+	if err != nil {
+		// Objects of the kinds that couldn't be discovered might be in the namespace.
+		return fmt.Errorf("failed to discover the kinds of objects in namespace %q (%v): %w", name, err, ErrIngressNamespaceKept)
+	}
+	foreign, err := foreignObjects(name, kinds, api)
+	if err != nil {
+		return fmt.Errorf("failed to check ingress namespace for foreign objects: %w", err)
+	}
+	if len(foreign) > 0 {
+		return fmt.Errorf("namespace %q contains objects not managed by the operator %v: %w", name, foreign, ErrIngressNamespaceKept)
+	}
+
+	log.Info("Deleting ingress namespace", "namespace", name)
+	if err := api.Delete(context.TODO(), ns); err != nil && !apierrors.IsNotFound(err) {
 		return fmt.Errorf("failed to remove ingress namespace: %w", err)
 	}
 	return nil
 }
 
+// ownedBy returns true if the namespace has been created by Manifestival for the given instance.
+func ownedBy(ns metav1.Object, instance *servingv1alpha1.KnativeServing) bool {
+	annotations := ns.GetAnnotations()
+	return annotations[manifestivalAnnotation] == manifestivalCreated &&
+		annotations[common.ServingOwnerName] == instance.GetName() &&
+		annotations[common.ServingOwnerNamespace] == instance.GetNamespace()
+}
+
+// ignoredKinds are the kinds of namespaced objects that don't keep a namespace from being
+// deleted, as they're derived from other objects or listed in every namespace.
+var ignoredKinds = map[schema.GroupKind]bool{
+	{Kind: "Endpoints"}:                                               true,
+	{Kind: "Event"}:                                                   true,
+	{Group: "events.k8s.io", Kind: "Event"}:                           true,
+	{Group: "metrics.k8s.io", Kind: "PodMetrics"}:                     true,
+	{Group: "packages.operators.coreos.com", Kind: "PackageManifest"}: true,
+}
+
+// namespacedKinds returns the listable kinds of namespaced objects served by the cluster, in
+// their preferred versions.
+func namespacedKinds(dc discovery.DiscoveryInterface) ([]schema.GroupVersionKind, error) {
+	lists, err := discovery.ServerPreferredNamespacedResources(dc)
+	if err != nil {
+		return nil, err
+	}
+	var kinds []schema.GroupVersionKind
+	for _, list := range discovery.FilteredBy(discovery.SupportsAllVerbs{Verbs: []string{"list"}}, lists) {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range list.APIResources {
+			if strings.Contains(resource.Name, "/") || ignoredKinds[gv.WithKind(resource.Kind).GroupKind()] {
+				// Subresources and ignored kinds
+				continue
+			}
+			kinds = append(kinds, gv.WithKind(resource.Kind))
+		}
+	}
+	return kinds, nil
+}
+
+// foreignObjects lists the objects of the given kinds in the given namespace that are not managed
+// by the operator. Dependents of other objects are left to their owners, and objects created by
+// the platform for every namespace are ignored.
+func foreignObjects(namespace string, kinds []schema.GroupVersionKind, api client.Client) ([]string, error) {
+	var foreign []string
+	for _, gvk := range kinds {
+		items, err := list(api, gvk, client.InNamespace(namespace))
+		if apierrors.IsForbidden(err) {
+			// Objects the operator may not see might be in the namespace.
+			return nil, fmt.Errorf("not allowed to list %ss (%v): %w", gvk.Kind, err, ErrIngressNamespaceKept)
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to list %ss: %w", gvk.Kind, err)
+		}
+		for i := range items {
+			item := &items[i]
+			if _, managed := item.GetAnnotations()[common.ServingOwnerName]; managed ||
+				len(item.GetOwnerReferences()) > 0 || createdByPlatform(item) {
+				continue
+			}
+			foreign = append(foreign, gvk.Kind+"/"+item.GetName())
+		}
+	}
+	return foreign, nil
+}
+
+// createdByPlatform returns true for objects OpenShift creates in every namespace.
+func createdByPlatform(u *unstructured.Unstructured) bool {
+	switch u.GetKind() {
+	case "ConfigMap":
+		return u.GetName() == "kube-root-ca.crt" || u.GetName() == "openshift-service-ca.crt"
+	case "Secret":
+		// The secrets of service accounts, which are foreign themselves if not created by the platform.
+		secretType, _, _ := unstructured.NestedString(u.Object, "type")
+		_, forServiceAccount := u.GetAnnotations()[v1.ServiceAccountNameKey]
+		return forServiceAccount &&
+			(secretType == string(v1.SecretTypeServiceAccountToken) || secretType == string(v1.SecretTypeDockercfg))
+	case "ServiceAccount":
+		return u.GetName() == "default" || u.GetName() == "builder" || u.GetName() == "deployer"
+	case "RoleBinding":
+		return u.GetName() == "system:image-pullers" || u.GetName() == "system:image-builders" ||
+			u.GetName() == "system:deployers"
+	}
+	return false
+}
+
+// list lists the objects of the given kind. Unstructured objects are used, so the objects are read
+// from the API server directly instead of starting informers on e.g. all secrets of the cluster.
+func list(api client.Client, gvk schema.GroupVersionKind, opts ...client.ListOption) ([]unstructured.Unstructured, error) {
+	l := &unstructured.UnstructuredList{}
+	l.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := api.List(context.TODO(), l, opts...); err != nil {
+		return nil, err
+	}
+	return l.Items, nil
+}
+
 // replaceImageFromEnvironment replaces Kourier images with the images specified by env value.
 // This func is copied from serving/operator/pkg/controller/knativeserving/common/transform.go and modified.
 func replaceImageFromEnvironment(prefix string, scheme *runtime.Scheme) mf.Transformer {
@@ -157,14 +405,24 @@ func RawManifest(apiclient client.Client) (mf.Manifest, error) {
 	return mfc.NewManifest(manifestPath(), apiclient, mf.UseLogger(log.WithName("mf")))
 }
 
+// Manifest returns the kourier manifest transformed for the instance, as it's applied.
+func Manifest(instance *servingv1alpha1.KnativeServing, apiclient client.Client, scheme *runtime.Scheme) (mf.Manifest, error) {
+	return manifest(common.IngressNamespace(instance), apiclient, instance, scheme)
+}
+
 // manifest returns kourier manifest after transformed
 func manifest(namespace string, apiclient client.Client, instance *servingv1alpha1.KnativeServing, scheme *runtime.Scheme) (mf.Manifest, error) {
 	manifest, err := RawManifest(apiclient)
 	if err != nil {
 		return mf.Manifest{}, err
 	}
+	labels, annotations, err := common.IngressNamespaceMetadata(instance)
+	if err != nil {
+		return mf.Manifest{}, err
+	}
 	transforms := []mf.Transformer{
 		mf.InjectNamespace(namespace),
+		namespaceMetadata(labels, annotations),
 		replaceImageFromEnvironment("IMAGE_", scheme),
 		common.SetAnnotations(map[string]string{
 			common.ServingOwnerName:      instance.Name,
@@ -172,10 +430,93 @@ func manifest(namespace string, apiclient client.Client, instance *servingv1alph
 		}),
 		replaceDeploymentInstanceCount(instance.Spec.HighAvailability, scheme),
 		replaceEnvValue(namespace, scheme),
+		replaceBootstrapXDSAddress(namespace),
 	}
 	return manifest.Transform(transforms...)
 }
 
+// replaceBootstrapXDSAddress points the gateway's Envoy bootstrap at the control plane
+// in the given ingress namespace. The release manifest hardcodes the default namespace.
+func replaceBootstrapXDSAddress(namespace string) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "ConfigMap" || u.GetName() != bootstrapConfigMap {
+			return nil
+		}
+		data, found, err := unstructured.NestedString(u.Object, "data", bootstrapKey)
+		if err != nil || !found {
+			return err
+		}
+		bootstrap := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(data), &bootstrap); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", bootstrapKey, err)
+		}
+		clusters, _, err := unstructured.NestedSlice(bootstrap, "static_resources", "clusters")
+		if err != nil {
+			return err
+		}
+		address := controlService + "." + namespace
+		changed := false
+		for _, cluster := range clusters {
+			if c, ok := cluster.(map[string]interface{}); ok && c["name"] == xdsCluster {
+				changed = setSocketAddresses(c, address) || changed
+			}
+		}
+		if !changed {
+			return nil
+		}
+		if err := unstructured.SetNestedSlice(bootstrap, clusters, "static_resources", "clusters"); err != nil {
+			return err
+		}
+		out, err := yaml.Marshal(bootstrap)
+		if err != nil {
+			return err
+		}
+		return unstructured.SetNestedField(u.Object, string(out), "data", bootstrapKey)
+	}
+}
+
+// setSocketAddresses sets the address of every socket_address found in value and
+// reports whether any of them changed.
+func setSocketAddresses(value interface{}, address string) bool {
+	changed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if sa, ok := child.(map[string]interface{}); ok && key == "socket_address" {
+				if sa["address"] != address {
+					sa["address"] = address
+					changed = true
+				}
+				continue
+			}
+			changed = setSocketAddresses(child, address) || changed
+		}
+	case []interface{}:
+		for _, child := range v {
+			changed = setSocketAddresses(child, address) || changed
+		}
+	}
+	return changed
+}
+
+// namespaceMetadata adds the given labels and annotations to the ingress namespace.
+func namespaceMetadata(labels, annotations map[string]string) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "Namespace" {
+			return nil
+		}
+		merged := u.GetLabels()
+		if merged == nil {
+			merged = map[string]string{}
+		}
+		for k, v := range labels {
+			merged[k] = v
+		}
+		u.SetLabels(merged)
+		return common.SetAnnotations(annotations)(u)
+	}
+}
+
 func manifestPath() string {
 	return os.Getenv("KOURIER_MANIFEST_PATH")
 }
@@ -1,13 +1,28 @@
 package kourier
 
 import (
+	"context"
+	"errors"
 	"os"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	mfc "github.com/manifestival/controller-runtime-client"
+	mf "github.com/manifestival/manifestival"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
 	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
 )
 
 func TestReplaceImageFromEnvironment(t *testing.T) {
@@ -64,3 +79,272 @@ func TestReplaceImageFromEnvironment(t *testing.T) {
 		}
 	}
 }
+
+func TestManifestIngressNamespace(t *testing.T) {
+	defer os.Setenv("KOURIER_MANIFEST_PATH", os.Getenv("KOURIER_MANIFEST_PATH"))
+	os.Setenv("KOURIER_MANIFEST_PATH", "testdata/kourier-latest.yaml")
+
+	instance := &servingv1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "knative-serving",
+			Namespace:   "knative-serving",
+			Annotations: map[string]string{common.IngressNamespaceAnnotation: "my-ingress"},
+		},
+	}
+	manifest, err := Manifest(instance, fake.NewFakeClient(), scheme.Scheme)
+	if err != nil {
+		t.Fatalf("Manifest() = %v", err)
+	}
+
+	bootstrap := manifest.Filter(mf.ByKind("ConfigMap"), mf.ByName("kourier-bootstrap")).Resources()
+	if len(bootstrap) != 1 {
+		t.Fatalf("Got %d kourier-bootstrap ConfigMaps, want 1", len(bootstrap))
+	}
+	if ns := bootstrap[0].GetNamespace(); ns != "my-ingress" {
+		t.Errorf("Namespace = %s, want my-ingress", ns)
+	}
+	data, _, _ := unstructured.NestedString(bootstrap[0].Object, "data", "envoy-bootstrap.yaml")
+	config := struct {
+		StaticResources struct {
+			Clusters []struct {
+				Name  string `json:"name"`
+				Hosts []struct {
+					SocketAddress struct {
+						Address string `json:"address"`
+					} `json:"socket_address"`
+				} `json:"hosts"`
+			} `json:"clusters"`
+		} `json:"static_resources"`
+	}{}
+	if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatalf("Failed to parse the bootstrap: %v", err)
+	}
+	var addresses []string
+	for _, cluster := range config.StaticResources.Clusters {
+		if cluster.Name != "xds_cluster" {
+			continue
+		}
+		for _, host := range cluster.Hosts {
+			addresses = append(addresses, host.SocketAddress.Address)
+		}
+	}
+	if want := []string{"kourier-control.my-ingress"}; !cmp.Equal(addresses, want) {
+		t.Errorf("xds_cluster addresses = %v, want %v", addresses, want)
+	}
+}
+
+func TestDeleteIngressNamespace(t *testing.T) {
+	instance := &servingv1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{Name: "knative-serving", Namespace: "knative-serving"},
+	}
+	owned := map[string]string{
+		manifestivalAnnotation:       manifestivalCreated,
+		common.ServingOwnerName:      "knative-serving",
+		common.ServingOwnerNamespace: "knative-serving",
+	}
+	namespace := func(annotations map[string]string) *v1.Namespace {
+		return &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "knative-serving-ingress", Annotations: annotations}}
+	}
+	configMap := func(name string, annotations map[string]string) *v1.ConfigMap {
+		return &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "knative-serving-ingress", Annotations: annotations}}
+	}
+
+	tests := []struct {
+		name      string
+		objs      []runtime.Object
+		failing   []string
+		wantKept  bool
+		wantExist bool
+	}{{
+		name: "no namespace",
+	}, {
+		name: "created by the operator",
+		objs: []runtime.Object{
+			namespace(owned),
+			configMap("kourier-bootstrap", owned),
+			configMap("kube-root-ca.crt", nil),
+			&v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "default-token-abcde",
+					Namespace:   "knative-serving-ingress",
+					Annotations: map[string]string{v1.ServiceAccountNameKey: "default"},
+				},
+				Type: v1.SecretTypeServiceAccountToken,
+			},
+			&v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "knative-serving-ingress"}},
+			&v1.Event{ObjectMeta: metav1.ObjectMeta{Name: "kourier.event", Namespace: "knative-serving-ingress"}},
+		},
+	}, {
+		name:      "not created by the operator",
+		objs:      []runtime.Object{namespace(nil)},
+		wantKept:  true,
+		wantExist: true,
+	}, {
+		name: "created for another instance",
+		objs: []runtime.Object{namespace(map[string]string{
+			manifestivalAnnotation:       manifestivalCreated,
+			common.ServingOwnerName:      "other",
+			common.ServingOwnerNamespace: "knative-serving",
+		})},
+		wantKept:  true,
+		wantExist: true,
+	}, {
+		name:      "contains foreign objects",
+		objs:      []runtime.Object{namespace(owned), configMap("users-config", nil)},
+		wantKept:  true,
+		wantExist: true,
+	}, {
+		name: "contains foreign objects of other kinds",
+		objs: []runtime.Object{namespace(owned), &v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "users-data", Namespace: "knative-serving-ingress"},
+		}},
+		wantKept:  true,
+		wantExist: true,
+	}, {
+		name: "contains a token secret not created for a service account",
+		objs: []runtime.Object{namespace(owned), &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "users-token", Namespace: "knative-serving-ingress"},
+			Type:       v1.SecretTypeServiceAccountToken,
+		}},
+		wantKept:  true,
+		wantExist: true,
+	}, {
+		name:      "kinds not discovered",
+		objs:      []runtime.Object{namespace(owned)},
+		failing:   []string{"apps/v1"},
+		wantKept:  true,
+		wantExist: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			api := fake.NewFakeClient(test.objs...)
+
+			err := deleteIngressNamespace("knative-serving-ingress", instance, api, newFakeDiscovery(test.failing...))
+			if kept := errors.Is(err, ErrIngressNamespaceKept); kept != test.wantKept {
+				t.Errorf("deleteIngressNamespace() = %v, want kept %v", err, test.wantKept)
+			}
+			if err != nil && !test.wantKept {
+				t.Fatalf("deleteIngressNamespace() = %v", err)
+			}
+
+			err = api.Get(context.TODO(), client.ObjectKey{Name: "knative-serving-ingress"}, &v1.Namespace{})
+			if exists := !apierrors.IsNotFound(err); exists != test.wantExist {
+				t.Errorf("Namespace exists = %v, want %v", exists, test.wantExist)
+			}
+		})
+	}
+}
+
+func TestDeletePreviousNamespaces(t *testing.T) {
+	defer os.Setenv("KOURIER_MANIFEST_PATH", os.Getenv("KOURIER_MANIFEST_PATH"))
+	os.Setenv("KOURIER_MANIFEST_PATH", "testdata/kourier-latest.yaml")
+	servingv1alpha1.AddToScheme(scheme.Scheme)
+
+	instance := &servingv1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "knative-serving",
+			Namespace: "knative-serving",
+			Annotations: map[string]string{
+				common.IngressNamespaceAnnotation:         "new-ingress",
+				common.AppliedIngressNamespacesAnnotation: "old-ingress,kept-ingress,new-ingress",
+			},
+		},
+	}
+	owned := map[string]string{
+		manifestivalAnnotation:       manifestivalCreated,
+		common.ServingOwnerName:      "knative-serving",
+		common.ServingOwnerNamespace: "knative-serving",
+	}
+	api := fake.NewFakeClient(
+		instance,
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "old-ingress", Annotations: owned}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "3scale-kourier-gateway", Namespace: "old-ingress", Annotations: owned}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kept-ingress"}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "new-ingress", Annotations: owned}},
+	)
+	if err := api.Get(context.TODO(), client.ObjectKey{Namespace: "knative-serving", Name: "knative-serving"}, instance); err != nil {
+		t.Fatalf("get: (%v)", err)
+	}
+
+	if err := deletePreviousNamespaces("new-ingress", instance, api, newFakeDiscovery(), scheme.Scheme); err != nil {
+		t.Fatalf("deletePreviousNamespaces() = %v", err)
+	}
+	instance.Status.MarkDependenciesInstalled()
+	if err := recordAppliedNamespaces([]string{"new-ingress"}, instance, api); err != nil {
+		t.Fatalf("recordAppliedNamespaces() = %v", err)
+	}
+	// The status computed before is kept and can still be written.
+	if cond := instance.Status.GetCondition(servingv1alpha1.DependenciesInstalled); cond == nil || !cond.IsTrue() {
+		t.Errorf("DependenciesInstalled = %v, want the status to be kept", cond)
+	}
+	if err := api.Status().Update(context.TODO(), instance); err != nil {
+		t.Errorf("Status().Update() = %v", err)
+	}
+
+	for _, test := range []struct {
+		obj       runtime.Object
+		key       client.ObjectKey
+		wantExist bool
+	}{
+		{obj: &v1.Namespace{}, key: client.ObjectKey{Name: "old-ingress"}},
+		{obj: &appsv1.Deployment{}, key: client.ObjectKey{Namespace: "old-ingress", Name: "3scale-kourier-gateway"}},
+		{obj: &v1.Namespace{}, key: client.ObjectKey{Name: "kept-ingress"}, wantExist: true},
+		{obj: &v1.Namespace{}, key: client.ObjectKey{Name: "new-ingress"}, wantExist: true},
+	} {
+		err := api.Get(context.TODO(), test.key, test.obj)
+		if exists := !apierrors.IsNotFound(err); exists != test.wantExist {
+			t.Errorf("%T %v exists = %v, want %v", test.obj, test.key, exists, test.wantExist)
+		}
+	}
+
+	got := &servingv1alpha1.KnativeServing{}
+	if err := api.Get(context.TODO(), client.ObjectKey{Namespace: "knative-serving", Name: "knative-serving"}, got); err != nil {
+		t.Fatalf("get: (%v)", err)
+	}
+	if applied := got.Annotations[common.AppliedIngressNamespacesAnnotation]; applied != "new-ingress" {
+		t.Errorf("Applied ingress namespaces = %q, want %q", applied, "new-ingress")
+	}
+}
+
+// failingDiscovery fails to discover the resources of the given group versions.
+type failingDiscovery struct {
+	*fakediscovery.FakeDiscovery
+	failing []string
+}
+
+func (d *failingDiscovery) ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error) {
+	for _, gv := range d.failing {
+		if gv == groupVersion {
+			return nil, errors.New("discovery failed")
+		}
+	}
+	return d.FakeDiscovery.ServerResourcesForGroupVersion(groupVersion)
+}
+
+// newFakeDiscovery returns a discovery client serving the kinds of the tests, failing for the
+// given group versions.
+func newFakeDiscovery(failing ...string) *failingDiscovery {
+	resource := func(name, kind string, verbs ...string) metav1.APIResource {
+		return metav1.APIResource{Name: name, Kind: kind, Namespaced: true, Verbs: verbs}
+	}
+	list := []string{"get", "list", "watch", "delete"}
+	return &failingDiscovery{
+		FakeDiscovery: &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{{
+			GroupVersion: v1.SchemeGroupVersion.String(),
+			APIResources: []metav1.APIResource{
+				resource("configmaps", "ConfigMap", list...),
+				resource("secrets", "Secret", list...),
+				resource("serviceaccounts", "ServiceAccount", list...),
+				resource("serviceaccounts/token", "TokenRequest", "create"),
+				resource("persistentvolumeclaims", "PersistentVolumeClaim", list...),
+				resource("events", "Event", list...),
+				resource("bindings", "Binding", "create"),
+			},
+		}, {
+			GroupVersion: appsv1.SchemeGroupVersion.String(),
+			APIResources: []metav1.APIResource{resource("deployments", "Deployment", list...)},
+		}}}},
+		failing: failing,
+	}
+}
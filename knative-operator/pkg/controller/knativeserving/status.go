@@ -0,0 +1,39 @@
+package knativeserving
+
+import (
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/pkg/apis"
+)
+
+// Conditions reflecting the OpenShift specific stages of the KnativeServing reconciliation.
+// They are informational and don't affect the Ready condition, except for Kourier, which
+// is also reflected in the DependenciesInstalled condition.
+const (
+	// KourierReady reflects the installation of the Kourier ingress.
+	KourierReady apis.ConditionType = "KourierReady"
+	// CustomCertsReady reflects the ConfigMaps injecting custom certificates into the controller.
+	CustomCertsReady apis.ConditionType = "CustomCertsReady"
+	// DashboardInstalled reflects the installation of the Serving dashboard in the console.
+	DashboardInstalled apis.ConditionType = "DashboardInstalled"
+	// ServiceMonitorsInstalled reflects the installation of the ServiceMonitors of the Serving control plane.
+	ServiceMonitorsInstalled apis.ConditionType = "ServiceMonitorsInstalled"
+	// AlertsInstalled reflects the installation of the Serving alerting rules.
+	AlertsInstalled apis.ConditionType = "AlertsInstalled"
+	// ProxySettingsReady reflects the cluster-wide proxy settings on the controller.
+	ProxySettingsReady apis.ConditionType = "ProxySettingsReady"
+	// CLIDownloadReady reflects the kn ConsoleCLIDownload.
+	CLIDownloadReady apis.ConditionType = "CLIDownloadReady"
+	// ConsoleResourcesInstalled reflects the YAML samples and quick starts in the console.
+	ConsoleResourcesInstalled apis.ConditionType = "ConsoleResourcesInstalled"
+	// CleanupSucceeded reflects the cleanup of the resources on deletion.
+	CleanupSucceeded apis.ConditionType = "CleanupSucceeded"
+)
+
+// servingCondSet mirrors the condition set of the upstream KnativeServing, so the
+// conditions above are managed as non-dependent conditions of the same happy condition.
+var servingCondSet = apis.NewLivingConditionSet(
+	servingv1alpha1.DependenciesInstalled,
+	servingv1alpha1.DeploymentsAvailable,
+	servingv1alpha1.InstallSucceeded,
+	servingv1alpha1.VersionMigrationEligible,
+)
@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"context"
+	"os"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common/logging"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = common.Log.WithName("logging-controller")
+
+// Add creates a new Controller applying the log levels configured in the logging ConfigMap and
+// adds it to the Manager. The Manager will set fields on the Controller and Start it when the
+// Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) *ReconcileLogging {
+	return &ReconcileLogging{
+		client: mgr.GetClient(),
+		name:   types.NamespacedName{Namespace: os.Getenv(common.NamespaceEnvKey), Name: logging.ConfigMapName},
+		levels: logging.DefaultLevels,
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r *ReconcileLogging) error {
+	// Create a new controller
+	c, err := controller.New("logging-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the logging ConfigMap only
+	return c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestForObject{}, predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return r.isConfigMap(e.Meta.GetNamespace(), e.Meta.GetName()) },
+		UpdateFunc:  func(e event.UpdateEvent) bool { return r.isConfigMap(e.MetaNew.GetNamespace(), e.MetaNew.GetName()) },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return r.isConfigMap(e.Meta.GetNamespace(), e.Meta.GetName()) },
+		GenericFunc: func(e event.GenericEvent) bool { return r.isConfigMap(e.Meta.GetNamespace(), e.Meta.GetName()) },
+	})
+}
+
+// blank assignment to verify that ReconcileLogging implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileLogging{}
+
+// ReconcileLogging applies the log levels of the logging ConfigMap
+type ReconcileLogging struct {
+	client client.Client
+	name   types.NamespacedName
+	levels *logging.Levels
+}
+
+// Reconcile reads the logging ConfigMap and updates the log levels. A missing ConfigMap resets
+// all loggers to their default level.
+func (r *ReconcileLogging) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	cm := &corev1.ConfigMap{}
+	if err := r.client.Get(context.TODO(), request.NamespacedName, cm); err != nil && !apierrors.IsNotFound(err) {
+		return reconcile.Result{}, err
+	}
+	if err := r.levels.Update(cm.Data); err != nil {
+		// Retrying won't help, the ConfigMap needs to be fixed.
+		log.Error(err, "Ignoring the logging configuration", "configmap", request.NamespacedName)
+		return reconcile.Result{}, nil
+	}
+	log.Info("Updated log levels", "levels", cm.Data)
+	return reconcile.Result{}, nil
+}
+
+func (r *ReconcileLogging) isConfigMap(namespace, name string) bool {
+	return namespace == r.name.Namespace && name == r.name.Name
+}
@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common/logging"
+	"go.uber.org/zap/zapcore"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var defaultRequest = reconcile.Request{
+	NamespacedName: types.NamespacedName{Namespace: "openshift-serverless", Name: logging.ConfigMapName},
+}
+
+func TestReconcile(t *testing.T) {
+	t.Cleanup(func() { logging.DefaultLevels.Update(nil) })
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: defaultRequest.Namespace, Name: defaultRequest.Name},
+		Data:       map[string]string{logging.LevelKeyPrefix + "controller": "debug"},
+	}
+	cl := fake.NewFakeClient(cm)
+	r := &ReconcileLogging{client: cl, name: defaultRequest.NamespacedName, levels: logging.DefaultLevels}
+
+	reconcileAndCheck := func(step string, wantControllerDebug bool) {
+		t.Helper()
+		if _, err := r.Reconcile(defaultRequest); err != nil {
+			t.Fatalf("%s: Reconcile() = %v", step, err)
+		}
+		if got := logging.DefaultLevels.EnabledFor("knative.openshift.controller", zapcore.DebugLevel); got != wantControllerDebug {
+			t.Errorf("%s: debug enabled for controller = %v, want %v", step, got, wantControllerDebug)
+		}
+		if logging.DefaultLevels.EnabledFor("knative.openshift.webhook", zapcore.DebugLevel) {
+			t.Errorf("%s: debug enabled for webhook, want the default level", step)
+		}
+		if got := logging.DefaultLevels.Enabled(zapcore.DebugLevel); got != wantControllerDebug {
+			t.Errorf("%s: debug enabled = %v, want %v", step, got, wantControllerDebug)
+		}
+	}
+
+	reconcileAndCheck("created", true)
+
+	cm.Data = map[string]string{logging.LevelKeyPrefix + "controller": "loud"}
+	if err := cl.Update(context.TODO(), cm); err != nil {
+		t.Fatalf("Failed to update ConfigMap: %v", err)
+	}
+	reconcileAndCheck("invalid level", true)
+
+	if err := cl.Delete(context.TODO(), cm); err != nil {
+		t.Fatalf("Failed to delete ConfigMap: %v", err)
+	}
+	reconcileAndCheck("deleted", false)
+}
@@ -0,0 +1,133 @@
+package queueproxymetrics
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	mfc "github.com/manifestival/controller-runtime-client"
+	mf "github.com/manifestival/manifestival"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// MetricsLabel opts a namespace in to the scraping of the request metrics of the queue-proxies
+	// of its Knative Services, if set to "true". They're scraped by OpenShift user-workload monitoring,
+	// which needs to be enabled on the cluster.
+	MetricsLabel = "serverless.openshift.io/queue-proxy-metrics"
+	// PodMonitorPathEnvVar is the path of the PodMonitor manifest applied to opted in namespaces.
+	PodMonitorPathEnvVar = "QUEUE_PROXY_POD_MONITOR_MANIFEST_PATH"
+
+	defaultPodMonitorPath = "deploy/resources/queue-proxy-pod-monitor.yaml"
+)
+
+var log = common.Log.WithName("queue-proxy-metrics-controller")
+
+// Add creates a new Controller provisioning the scraping of the queue-proxies in opted in
+// namespaces and adds it to the Manager, if the cluster serves PodMonitors. The Manager will set
+// fields on the Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	gvk := monitoringv1.SchemeGroupVersion.WithKind(monitoringv1.PodMonitorsKind)
+	if _, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+		if meta.IsNoMatchError(err) {
+			log.Info("PodMonitors not served by the cluster, not scraping queue-proxies")
+			return nil
+		}
+		return err
+	}
+	return add(mgr, &ReconcileQueueProxyMetrics{client: mgr.GetClient()})
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r *ReconcileQueueProxyMetrics) error {
+	c, err := controller.New("queue-proxy-metrics-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch the namespaces being opted in or out
+	err = c.Watch(&source.Kind{Type: &corev1.Namespace{}}, &handler.EnqueueRequestForObject{}, predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool { return enabled(e.Meta.GetLabels()) },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return enabled(e.MetaOld.GetLabels()) != enabled(e.MetaNew.GetLabels())
+		},
+		DeleteFunc: func(event.DeleteEvent) bool { return false },
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch the PodMonitors to restore them
+	return c.Watch(&source.Kind{Type: &monitoringv1.PodMonitor{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: obj.Meta.GetNamespace()}}}
+		}),
+	}, predicate.Funcs{
+		CreateFunc: func(event.CreateEvent) bool { return false },
+	})
+}
+
+// blank assignment to verify that ReconcileQueueProxyMetrics implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileQueueProxyMetrics{}
+
+// ReconcileQueueProxyMetrics applies the PodMonitor scraping the queue-proxies to the namespaces
+// opted in by MetricsLabel, and removes it from the other ones. The PodMonitor is all it creates:
+// the namespaces are never labeled for the platform monitoring stack, which would take them away
+// from user-workload monitoring.
+type ReconcileQueueProxyMetrics struct {
+	client client.Client
+}
+
+// Reconcile reconciles the PodMonitor of a namespace.
+func (r *ReconcileQueueProxyMetrics) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	ns := &corev1.Namespace{}
+	if err := r.client.Get(context.TODO(), request.NamespacedName, ns); apierrors.IsNotFound(err) {
+		// The PodMonitor is gone with the namespace.
+		return reconcile.Result{}, nil
+	} else if err != nil {
+		return reconcile.Result{}, err
+	}
+	manifest, err := manifest(ns.Name, r.client)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if !enabled(ns.Labels) || ns.DeletionTimestamp != nil {
+		return reconcile.Result{}, manifest.Delete()
+	}
+	log.Info("Provisioning the scraping of queue-proxies", "namespace", ns.Name)
+	if err := manifest.Apply(); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to apply queue-proxy PodMonitor: %w", err)
+	}
+	return reconcile.Result{}, nil
+}
+
+func enabled(labels map[string]string) bool {
+	return labels[MetricsLabel] == "true"
+}
+
+// manifest returns the PodMonitor manifest for the given namespace.
+func manifest(namespace string, api client.Client) (mf.Manifest, error) {
+	path := os.Getenv(PodMonitorPathEnvVar)
+	if path == "" {
+		path = defaultPodMonitorPath
+	}
+	manifest, err := mfc.NewManifest(path, api, mf.UseLogger(log.WithName("mf")))
+	if err != nil {
+		return mf.Manifest{}, fmt.Errorf("failed to read queue-proxy PodMonitor manifest: %w", err)
+	}
+	return manifest.Transform(mf.InjectNamespace(namespace))
+}
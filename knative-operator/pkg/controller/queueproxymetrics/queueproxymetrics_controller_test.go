@@ -0,0 +1,95 @@
+package queueproxymetrics
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const namespace = "user-ns"
+
+func init() {
+	apis.AddToScheme(scheme.Scheme)
+	os.Setenv(PodMonitorPathEnvVar, "../../../deploy/resources/queue-proxy-pod-monitor.yaml")
+}
+
+func TestReconcile(t *testing.T) {
+	for _, mode := range []string{common.MonitoringModeCluster, common.MonitoringModeUserWorkload} {
+		t.Run(mode, func(t *testing.T) {
+			defer os.Unsetenv(common.MonitoringModeEnvKey)
+			os.Setenv(common.MonitoringModeEnvKey, mode)
+
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:   namespace,
+				Labels: map[string]string{MetricsLabel: "true"},
+			}}
+			api := fake.NewFakeClient(ns)
+			r := &ReconcileQueueProxyMetrics{client: api}
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: namespace}}
+
+			if _, err := r.Reconcile(req); err != nil {
+				t.Fatalf("Reconcile() = %v", err)
+			}
+			podMonitor := &monitoringv1.PodMonitor{}
+			if err := api.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "knative-queue-proxy"}, podMonitor); err != nil {
+				t.Fatalf("Failed to get the PodMonitor: %v", err)
+			}
+			// The namespace is left to user-workload monitoring.
+			assertNotMonitoredByPlatform(t, api)
+
+			// Opting out removes the PodMonitor.
+			got := &corev1.Namespace{}
+			if err := api.Get(context.TODO(), req.NamespacedName, got); err != nil {
+				t.Fatal(err)
+			}
+			got.Labels[MetricsLabel] = "false"
+			if err := api.Update(context.TODO(), got); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := r.Reconcile(req); err != nil {
+				t.Fatalf("Reconcile() = %v", err)
+			}
+			err := api.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "knative-queue-proxy"}, &monitoringv1.PodMonitor{})
+			if !apierrors.IsNotFound(err) {
+				t.Errorf("PodMonitor not removed: %v", err)
+			}
+			assertNotMonitoredByPlatform(t, api)
+
+			// Deleted namespaces are ignored.
+			if err := api.Delete(context.TODO(), got); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := r.Reconcile(req); err != nil {
+				t.Fatalf("Reconcile() = %v", err)
+			}
+		})
+	}
+}
+
+func assertNotMonitoredByPlatform(t *testing.T, api client.Client) {
+	t.Helper()
+	ns := &corev1.Namespace{}
+	if err := api.Get(context.TODO(), types.NamespacedName{Name: namespace}, ns); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ns.Labels["openshift.io/cluster-monitoring"]; ok {
+		t.Errorf("Namespace labels = %v, want no cluster monitoring label", ns.Labels)
+	}
+	err := api.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "knative-serving-prometheus-k8s"}, &rbacv1.Role{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("Monitoring Role created: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package telemetrysummary
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common/telemetry"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var log = common.Log.WithName("telemetry-summary")
+
+// Add adds a runnable periodically writing the telemetry summary ConfigMap to the Manager, if a
+// summary interval is configured. The Manager starts it once it's the leader.
+func Add(mgr manager.Manager) error {
+	interval, err := telemetry.SummaryInterval()
+	if err != nil {
+		log.Error(err, "Ignoring the invalid telemetry summary interval", "env", telemetry.SummaryIntervalEnvKey)
+		return nil
+	}
+	if interval <= 0 {
+		return nil
+	}
+	return mgr.Add(newReporter(mgr.GetClient(), interval))
+}
+
+// reporter writes the telemetry summary to a ConfigMap in the operator namespace.
+type reporter struct {
+	client   client.Client
+	name     types.NamespacedName
+	interval time.Duration
+}
+
+func newReporter(api client.Client, interval time.Duration) *reporter {
+	return &reporter{
+		client:   api,
+		name:     types.NamespacedName{Namespace: os.Getenv(common.NamespaceEnvKey), Name: telemetry.SummaryConfigMapName},
+		interval: interval,
+	}
+}
+
+// Start writes the summary right away and then every interval until stopped.
+func (r *reporter) Start(stop <-chan struct{}) error {
+	log.Info("Writing the telemetry summary", "configmap", r.name, "interval", r.interval)
+	wait.Until(func() {
+		if err := r.report(context.TODO()); err != nil {
+			log.Error(err, "Failed to write the telemetry summary", "configmap", r.name)
+		}
+	}, r.interval, stop)
+	return nil
+}
+
+// report creates or updates the summary ConfigMap, if the summary changed.
+func (r *reporter) report(ctx context.Context) error {
+	data, err := telemetry.Summary(ctx, r.client)
+	if err != nil {
+		return err
+	}
+	cm := &corev1.ConfigMap{}
+	if err := r.client.Get(ctx, r.name, cm); apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: r.name.Namespace, Name: r.name.Name},
+			Data:       data,
+		}
+		return r.client.Create(ctx, cm)
+	} else if err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(cm.Data, data) {
+		return nil
+	}
+	cm.Data = data
+	return r.client.Update(ctx, cm)
+}
@@ -0,0 +1,69 @@
+package telemetrysummary
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common/telemetry"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	operatorv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func init() {
+	apis.AddToScheme(scheme.Scheme)
+	os.Setenv(common.NamespaceEnvKey, "openshift-serverless")
+}
+
+func TestReport(t *testing.T) {
+	serving := &operatorv1alpha1.KnativeServing{ObjectMeta: metav1.ObjectMeta{Namespace: "knative-serving", Name: "knative-serving"}}
+	serving.Status.SetVersion("0.18.2")
+	api := fake.NewFakeClient(serving)
+	r := newReporter(api, time.Hour)
+
+	if err := r.report(context.TODO()); err != nil {
+		t.Fatalf("report() = %v", err)
+	}
+	cm := &corev1.ConfigMap{}
+	if err := api.Get(context.TODO(), r.name, cm); err != nil {
+		t.Fatalf("Failed to get the summary ConfigMap: %v", err)
+	}
+	if r.name.Namespace != "openshift-serverless" || r.name.Name != telemetry.SummaryConfigMapName {
+		t.Errorf("Summary written to %v, want the operator namespace", r.name)
+	}
+	if got := cm.Data["serving.version"]; got != "0.18.2" {
+		t.Errorf("serving.version = %q, want 0.18.2", got)
+	}
+
+	// Unchanged summaries aren't written again.
+	version := cm.ResourceVersion
+	if err := r.report(context.TODO()); err != nil {
+		t.Fatalf("report() = %v", err)
+	}
+	if err := api.Get(context.TODO(), r.name, cm); err != nil {
+		t.Fatal(err)
+	}
+	if cm.ResourceVersion != version {
+		t.Error("Unchanged summary was updated")
+	}
+
+	serving.Status.SetVersion("0.19.0")
+	if err := api.Status().Update(context.TODO(), serving); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.report(context.TODO()); err != nil {
+		t.Fatalf("report() = %v", err)
+	}
+	if err := api.Get(context.TODO(), r.name, cm); err != nil {
+		t.Fatal(err)
+	}
+	if got := cm.Data["serving.version"]; got != "0.19.0" {
+		t.Errorf("serving.version = %q, want the updated version 0.19.0", got)
+	}
+}
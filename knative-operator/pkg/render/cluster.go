@@ -0,0 +1,103 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// cluster is a read-only client serving the cluster state the operator reads while building its
+// manifests from memory. Rendering doesn't change anything, so writes and lists fail.
+type cluster struct {
+	scheme  *runtime.Scheme
+	objects map[objectKey]runtime.Object
+}
+
+type objectKey struct {
+	gvk schema.GroupVersionKind
+	key client.ObjectKey
+}
+
+var _ client.Client = (*cluster)(nil)
+
+// newCluster returns a client serving the given objects.
+func newCluster(scheme *runtime.Scheme, objects ...runtime.Object) (*cluster, error) {
+	c := &cluster{scheme: scheme, objects: map[objectKey]runtime.Object{}}
+	for _, obj := range objects {
+		key, err := c.keyOf(obj)
+		if err != nil {
+			return nil, err
+		}
+		c.objects[key] = obj
+	}
+	return c, nil
+}
+
+func (c *cluster) keyOf(obj runtime.Object) (objectKey, error) {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return objectKey{}, err
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return objectKey{}, err
+	}
+	return objectKey{gvk: gvk, key: client.ObjectKey{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}}, nil
+}
+
+func (c *cluster) Get(_ context.Context, key client.ObjectKey, obj runtime.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return err
+	}
+	stored, ok := c.objects[objectKey{gvk: gvk, key: key}]
+	if !ok {
+		return apierrors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: strings.ToLower(gvk.Kind)}, key.Name)
+	}
+	out := reflect.ValueOf(obj)
+	if out.Kind() != reflect.Ptr || out.Type() != reflect.TypeOf(stored) {
+		return fmt.Errorf("cannot read %s %s into %T", gvk.Kind, key, obj)
+	}
+	out.Elem().Set(reflect.ValueOf(stored.DeepCopyObject()).Elem())
+	return nil
+}
+
+func (c *cluster) List(context.Context, runtime.Object, ...client.ListOption) error {
+	return errReadOnly("list")
+}
+
+func (c *cluster) Create(context.Context, runtime.Object, ...client.CreateOption) error {
+	return errReadOnly("create")
+}
+
+func (c *cluster) Delete(context.Context, runtime.Object, ...client.DeleteOption) error {
+	return errReadOnly("delete")
+}
+
+func (c *cluster) Update(context.Context, runtime.Object, ...client.UpdateOption) error {
+	return errReadOnly("update")
+}
+
+func (c *cluster) Patch(context.Context, runtime.Object, client.Patch, ...client.PatchOption) error {
+	return errReadOnly("patch")
+}
+
+func (c *cluster) DeleteAllOf(context.Context, runtime.Object, ...client.DeleteAllOfOption) error {
+	return errReadOnly("delete")
+}
+
+func (c *cluster) Status() client.StatusWriter {
+	return c
+}
+
+func errReadOnly(verb string) error {
+	return fmt.Errorf("cannot %s objects while rendering", verb)
+}
@@ -0,0 +1,263 @@
+// Package render renders the resources the operator applies for a Knative component,
+// without contacting an API server.
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/alerts"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/console"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/dashboard"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/knativekafka"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/knativeserving/kourier"
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/kubernetes/scheme"
+	knativev1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+func init() {
+	apis.AddToScheme(scheme.Scheme)
+}
+
+// Options configure the rendering.
+type Options struct {
+	// Domain is the ingress domain of the cluster, as set in the OpenShift ingress config.
+	Domain string
+}
+
+// Render returns the resources the operator applies for the given KnativeServing, KnativeEventing
+// or KnativeKafka YAML, starting with the instance as mutated by the operator. Like in the operator,
+// the manifest paths and image overrides are read from the environment.
+func Render(data []byte, opts Options) ([]unstructured.Unstructured, error) {
+	obj, _, err := serializer.NewCodecFactory(scheme.Scheme).UniversalDeserializer().Decode(data, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode instance: %w", err)
+	}
+	// Cluster state the operator reads is replaced by an in-memory client.
+	api, err := newCluster(scheme.Scheme, &configv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec:       configv1.IngressSpec{Domain: opts.Domain},
+	}, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: common.ConfigManagedNamespace},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up cluster state: %w", err)
+	}
+
+	switch instance := obj.(type) {
+	case *knativev1alpha1.KnativeServing:
+		return renderServing(instance, api)
+	case *knativev1alpha1.KnativeEventing:
+		return renderEventing(instance, api)
+	case *operatorv1alpha1.KnativeKafka:
+		return renderKafka(instance, api)
+	}
+	return nil, fmt.Errorf("unsupported kind %q", obj.GetObjectKind().GroupVersionKind().Kind)
+}
+
+func renderServing(instance *knativev1alpha1.KnativeServing, api client.Client) ([]unstructured.Unstructured, error) {
+	if err := common.Mutate(instance, api); err != nil {
+		return nil, fmt.Errorf("failed to mutate KnativeServing: %w", err)
+	}
+	resources, err := instanceResource(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := kourier.Manifest(instance, api, scheme.Scheme)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render kourier: %w", err)
+	}
+	resources = append(resources, manifest.Resources()...)
+
+	if resources, err = appendMonitoringRole(resources, instance, api); err != nil {
+		return nil, err
+	}
+	manifest, err = common.ServingServiceMonitorsManifest(api, instance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render service monitors: %w", err)
+	}
+	resources = append(resources, manifest.Resources()...)
+
+	for _, envVar := range []string{dashboard.ServingDashboardPathEnvVar, dashboard.ServingSLODashboardPathEnvVar} {
+		if resources, err = appendDashboard(resources, os.Getenv(envVar), instance, api); err != nil {
+			return nil, err
+		}
+	}
+	owner := map[string]string{
+		common.ServingOwnerName:      instance.Name,
+		common.ServingOwnerNamespace: instance.Namespace,
+	}
+	if resources, err = appendAlerts(resources, alerts.Path(alerts.ServingAlertsPathEnvVar), instance, owner, api); err != nil {
+		return nil, err
+	}
+	return appendConsole(resources, console.Path(console.ServingConsolePathEnvVar), owner, api)
+}
+
+func renderEventing(instance *knativev1alpha1.KnativeEventing, api client.Client) ([]unstructured.Unstructured, error) {
+	common.MutateEventing(instance)
+	resources, err := instanceResource(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	if resources, err = appendMonitoringRole(resources, instance, api); err != nil {
+		return nil, err
+	}
+	manifest, err := common.EventingBrokerServiceMonitorsManifest(api, instance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render service monitors: %w", err)
+	}
+	resources = append(resources, manifest.Resources()...)
+
+	for _, envVar := range []string{dashboard.EventingBrokerDashboardPathEnvVar, dashboard.EventingSourceDashboardPathEnvVar} {
+		if resources, err = appendDashboard(resources, os.Getenv(envVar), instance, api); err != nil {
+			return nil, err
+		}
+	}
+	owner := map[string]string{
+		common.EventingOwnerName:      instance.Name,
+		common.EventingOwnerNamespace: instance.Namespace,
+	}
+	if resources, err = appendAlerts(resources, alerts.Path(alerts.EventingAlertsPathEnvVar), instance, owner, api); err != nil {
+		return nil, err
+	}
+	return appendConsole(resources, console.Path(console.EventingConsolePathEnvVar), owner, api)
+}
+
+func renderKafka(instance *operatorv1alpha1.KnativeKafka, api client.Client) ([]unstructured.Unstructured, error) {
+	resources, err := instanceResource(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := knativekafka.Manifest(instance, api)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render KnativeKafka: %w", err)
+	}
+	resources = append(resources, manifest.Resources()...)
+
+	owner := map[string]string{
+		common.KafkaOwnerName:      instance.Name,
+		common.KafkaOwnerNamespace: instance.Namespace,
+	}
+	if resources, err = appendAlerts(resources, alerts.Path(alerts.KafkaAlertsPathEnvVar), instance, owner, api); err != nil {
+		return nil, err
+	}
+	if !instance.Spec.Source.Enabled {
+		return resources, nil
+	}
+	return appendConsole(resources, console.Path(console.KafkaConsolePathEnvVar), owner, api)
+}
+
+// appendMonitoringRole appends the RBAC letting the platform monitoring stack scrape the namespace
+// of the instance. The namespaces of the instances are monitored by the platform in any monitoring
+// mode, so it's always applied.
+func appendMonitoringRole(resources []unstructured.Unstructured, instance knativev1alpha1.KComponent, api client.Client) ([]unstructured.Unstructured, error) {
+	manifest, err := common.MonitoringRoleManifest(api, instance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render monitoring RBAC: %w", err)
+	}
+	return append(resources, manifest.Resources()...), nil
+}
+
+// appendDashboard appends the dashboard resources, if a dashboard is configured.
+func appendDashboard(resources []unstructured.Unstructured, path string, instance knativev1alpha1.KComponent, api client.Client) ([]unstructured.Unstructured, error) {
+	if path == "" {
+		return resources, nil
+	}
+	manifest, err := dashboard.Manifest(path, instance, api)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render dashboard: %w", err)
+	}
+	return append(resources, manifest.Resources()...), nil
+}
+
+// appendAlerts appends the alerting rules, with the overrides of the instance.
+func appendAlerts(resources []unstructured.Unstructured, path string, instance metav1.Object, owner map[string]string, api client.Client) ([]unstructured.Unstructured, error) {
+	manifest, err := alerts.Manifest(path, instance, owner, api)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render alerts: %w", err)
+	}
+	return append(resources, manifest.Resources()...), nil
+}
+
+func appendConsole(resources []unstructured.Unstructured, path string, owner map[string]string, api client.Client) ([]unstructured.Unstructured, error) {
+	manifest, err := console.Manifest(path, owner, api)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render console resources: %w", err)
+	}
+	return append(resources, manifest.Resources()...), nil
+}
+
+// instanceResource returns the instance without its status.
+func instanceResource(instance runtime.Object) ([]unstructured.Unstructured, error) {
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(instance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert instance: %w", err)
+	}
+	u := unstructured.Unstructured{Object: obj}
+	unstructured.RemoveNestedField(u.Object, "status")
+	unstructured.RemoveNestedField(u.Object, "metadata", "creationTimestamp")
+	return []unstructured.Unstructured{u}, nil
+}
+
+// YAML returns the resources as multi-document YAML.
+func YAML(resources []unstructured.Unstructured) ([]byte, error) {
+	var b bytes.Buffer
+	for i := range resources {
+		data, err := yaml.Marshal(resources[i].Object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s %s: %w", resources[i].GetKind(), resources[i].GetName(), err)
+		}
+		b.WriteString("---\n")
+		b.Write(data)
+	}
+	return b.Bytes(), nil
+}
+
+// SetEnvFromFile sets the environment variables in the given file, one KEY=VALUE per line.
+// Empty lines and lines starting with # are ignored. Relative paths in the values of the *_PATH
+// variables are resolved against the directory of the file.
+func SetEnvFromFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		pair := strings.SplitN(text, "=", 2)
+		if len(pair) != 2 {
+			return fmt.Errorf("%s:%d: expected KEY=VALUE", path, line)
+		}
+		key, value := strings.TrimSpace(pair[0]), strings.TrimSpace(pair[1])
+		if strings.HasSuffix(key, "_PATH") && value != "" && !filepath.IsAbs(value) {
+			value = filepath.Join(filepath.Dir(path), value)
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
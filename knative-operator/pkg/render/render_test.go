@@ -0,0 +1,49 @@
+package render
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestRender(t *testing.T) {
+	if err := SetEnvFromFile("testdata/operator.env"); err != nil {
+		t.Fatalf("Failed to set environment: %v", err)
+	}
+
+	for _, name := range []string{"knativeserving", "knativeeventing", "knativekafka"} {
+		t.Run(name, func(t *testing.T) {
+			data, err := ioutil.ReadFile("testdata/" + name + ".yaml")
+			if err != nil {
+				t.Fatalf("Failed to read instance: %v", err)
+			}
+			resources, err := Render(data, Options{Domain: "apps.example.com"})
+			if err != nil {
+				t.Fatalf("Render() = %v", err)
+			}
+			got, err := YAML(resources)
+			if err != nil {
+				t.Fatalf("YAML() = %v", err)
+			}
+
+			golden := "testdata/" + name + ".golden.yaml"
+			if *update {
+				if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+					t.Fatalf("Failed to update golden file: %v", err)
+				}
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("Failed to read golden file: %v", err)
+			}
+			if !cmp.Equal(string(got), string(want)) {
+				t.Errorf("Rendered resources differ from %s (run with -update to update it), diff: %s",
+					golden, cmp.Diff(string(want), string(got)))
+			}
+		})
+	}
+}
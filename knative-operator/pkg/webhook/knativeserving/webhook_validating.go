@@ -44,6 +44,7 @@ func (v *Validator) validate(ctx context.Context, ks *servingv1alpha1.KnativeSer
 	stages := []func(context.Context, *servingv1alpha1.KnativeServing) (bool, string, error){
 		v.validateNamespace,
 		v.validateLoneliness,
+		v.validateIngressNamespace,
 	}
 	for _, stage := range stages {
 		allowed, reason, err = stage(ctx, ks)
@@ -103,3 +104,11 @@ func (v *Validator) validateLoneliness(ctx context.Context, ks *servingv1alpha1.
 	}
 	return true, "", nil
 }
+
+// validate the ingress namespace settings
+func (v *Validator) validateIngressNamespace(ctx context.Context, ks *servingv1alpha1.KnativeServing) (bool, string, error) {
+	if err := common.ValidateIngressNamespace(ks); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/testutil"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -69,3 +70,24 @@ func TestLoneliness(t *testing.T) {
 		t.Errorf("Too many KnativeServings: %v", result.AdmissionResponse)
 	}
 }
+
+func TestInvalidIngressNamespace(t *testing.T) {
+	os.Clearenv()
+
+	validator := Validator{}
+	validator.InjectDecoder(decoder)
+	validator.InjectClient(fake.NewFakeClient())
+
+	ks := ks1.DeepCopy()
+	ks.Namespace = "knative-serving"
+	ks.Annotations = map[string]string{common.IngressNamespaceAnnotation: "Not_A_Namespace"}
+	req, err := testutil.RequestFor(ks)
+	if err != nil {
+		t.Fatalf("Failed to generate a request for %v: %v", ks, err)
+	}
+
+	result := validator.Handle(context.Background(), req)
+	if result.Allowed {
+		t.Error("The ingress namespace is invalid, but the request is allowed")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
 	"github.com/openshift-knative/serverless-operator/test"
 	v1a1test "github.com/openshift-knative/serverless-operator/test/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
@@ -38,10 +39,13 @@ func TestKnativeServing(t *testing.T) {
 		}
 	})
 
+	ingressNamespace := servingNamespace + "-ingress"
 	t.Run("deploy knativeserving cr and wait for it to be ready", func(t *testing.T) {
-		if _, err := v1a1test.WithKnativeServingReady(caCtx, servingName, servingNamespace); err != nil {
+		ks, err := v1a1test.WithKnativeServingReady(caCtx, servingName, servingNamespace)
+		if err != nil {
 			t.Fatal("Failed to deploy KnativeServing", err)
 		}
+		ingressNamespace = common.IngressNamespace(ks)
 	})
 
 	t.Run("verify correct deployment shape", func(t *testing.T) {
@@ -61,10 +65,10 @@ func TestKnativeServing(t *testing.T) {
 		for _, deployment := range []string{"3scale-kourier-control", "3scale-kourier-gateway"} {
 			// Workaround for https://issues.redhat.com/browse/SRVCOM-1008 - wait for Kourier deployments to
 			// be ready before checking their scales.
-			if _, err := test.WithDeploymentReady(caCtx, deployment, servingNamespace+"-ingress"); err != nil {
+			if _, err := test.WithDeploymentReady(caCtx, deployment, ingressNamespace); err != nil {
 				t.Fatal("Failed", err)
 			}
-			if err := test.CheckDeploymentScale(caCtx, servingNamespace+"-ingress", deployment, haReplicas); err != nil {
+			if err := test.CheckDeploymentScale(caCtx, ingressNamespace, deployment, haReplicas); err != nil {
 				t.Fatalf("Failed to verify default HA settings: %v", err)
 			}
 		}
@@ -85,7 +89,7 @@ func TestKnativeServing(t *testing.T) {
 			t.Fatal("Failed to remove Knative Serving", err)
 		}
 
-		ns, err := caCtx.Clients.Kube.CoreV1().Namespaces().Get(context.Background(), servingNamespace+"-ingress", metav1.GetOptions{})
+		ns, err := caCtx.Clients.Kube.CoreV1().Namespaces().Get(context.Background(), ingressNamespace, metav1.GetOptions{})
 		if apierrs.IsNotFound(err) {
 			// Namespace is already gone, all good!
 			return
@@ -37,8 +37,8 @@ func TestConsoleCLIDownloadAndDeploymentResources(t *testing.T) {
 		t.Fatalf("unable to GET kn ConsoleCLIDownload CO 'kn': %v", err)
 	}
 	// Verify the links in kn CCD CO
-	if len(ccd.Spec.Links) != 3 {
-		t.Fatalf("expecting 3 links for artifacts for kn ConsoleCLIDownload, found %d", len(ccd.Spec.Links))
+	if len(ccd.Spec.Links) < 3 {
+		t.Fatalf("expecting at least 3 links for artifacts for kn ConsoleCLIDownload, found %d", len(ccd.Spec.Links))
 	}
 	// Verify if individual link starts with correct route
 	protocol := "https://"
@@ -58,6 +58,10 @@ func TestConsoleCLIDownloadAndDeploymentResources(t *testing.T) {
 		if err != nil {
 			t.Fatalf("failed to HEAD request for URL %s, error: %v", link.Href, err)
 		}
+		// Only the kn archives are expected to be big, checksum files aren't.
+		if !strings.HasSuffix(link.Href, ".tar.gz") && !strings.HasSuffix(link.Href, ".zip") {
+			continue
+		}
 		if h.ContentLength < 1024*1024*10 {
 			t.Fatalf("failed to verify kn CCD, kn artifact %s size less than 10MB", link.Href)
 		}